@@ -4,14 +4,23 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"syscall"
 	"taskm/pkg/archiver"
+	"taskm/pkg/dag"
+	"taskm/pkg/events"
+	"taskm/pkg/inspector"
 	"taskm/pkg/logger"
+	"taskm/pkg/logrotate"
+	"taskm/pkg/result"
+	"taskm/pkg/retry"
 	"taskm/pkg/storage"
 	"taskm/pkg/task"
 	"taskm/pkg/utils"
@@ -21,35 +30,88 @@ import (
 const (
 	storageDir = "storage"
 	tasksFileName = "tasks.txt"
+	walDirName = "wal"
 	archiverFileName = "archive.txt"
+	resultsDirName = "results"
+	archiveResultsDirName = "archive-results"
 	logFileName = "log.txt"
+	eventsFileName = "events.txt"
+	auditRingSize = 500 // сколько последних событий хранит кольцевой буфер для `taskm -audit`
 	logBuffer = 100
 	archiverInterval = 30 * time.Second
 	defaultPriority = task.PriorityMedium
+	logMaxSizeBytes = 5 * 1024 * 1024
+	archiveMaxSizeBytes = 10 * 1024 * 1024
+	rotateMaxBackups = 5
+	shutdownTimeout = 5 * time.Second // дедлайн Logger.Shutdown на финальный дренаж канала
 )
 
+// saveTasksRetryPolicy - политика повторов для финального сохранения задач
+// при завершении работы: транзиентная ошибка диска не должна стоить нам
+// несохраненных изменений.
+var saveTasksRetryPolicy = retry.Policy{
+	MaxAttempts: 3,
+	BaseDelay:   200 * time.Millisecond,
+	Factor:      2,
+	MaxDelay:    2 * time.Second,
+}
+
+var defaultLogRotateConfig = logrotate.RotateConfig{
+	MaxSizeBytes: logMaxSizeBytes,
+	MaxBackups:   rotateMaxBackups,
+	Compress:     true,
+}
+
+var defaultArchiveRotateConfig = logrotate.RotateConfig{
+	MaxSizeBytes: archiveMaxSizeBytes,
+	MaxBackups:   rotateMaxBackups,
+	Compress:     true,
+}
+
 var (
 	tasks []task.Task
 	taskMutex sync.Mutex
+	archiveFileMutex sync.Mutex // общий для appArchiver и appInspector, см. их конструирование в main()
 	idGen *utils.IDGenerator
 	appLogger *logger.Logger
 	appArchiver *archiver.Archiver
+	appInspector *inspector.Inspector
+	taskStore *storage.Store
+	writebackStore *storage.WritebackStore // non-nil только при -writeback>0, см. main()
+	eventBus *events.Bus
+	auditRing *events.RingBufferSubscriber
+	resultRegistry *result.Registry
+	archiveResultRegistry *result.Registry
 	wg sync.WaitGroup
+	// loggerWG - отдельная WaitGroup только для горутины логгера, не та, на
+	// которой блокируется основной wg.Wait() при завершении работы. Горутина
+	// логгера теперь останавливается явным appLogger.Shutdown(...) (см. main),
+	// а не через ctx.Done(), и делает это уже ПОСЛЕ того, как главный wg.Wait()
+	// дождался архиватора/writeback/watcher/eventBus - включи логгер в тот же
+	// wg, и wg.Wait() встал бы намертво в ожидании горутины, которая еще не
+	// получила команду на остановку.
+	loggerWG sync.WaitGroup
 )
 
-type byPriorityAndDate []task.Task
+// byPriorityAndDate сортирует задачи по приоритету (High -> Medium -> Low), а
+// внутри одного приоритета - по топологическому порядку зависимостей
+// (topoRank), если он задан, и дате создания как финальным критерием.
+type byPriorityAndDate struct {
+	tasks    []task.Task
+	topoRank map[int]int // ID задачи -> позиция в топологическом порядке
+}
 
 func (s byPriorityAndDate) Len() int {
-	return len(s)
+	return len(s.tasks)
 }
 
 func (s byPriorityAndDate) Swap(i, j int) {
-	s[i], s[j] = s[j], s[i]
+	s.tasks[i], s.tasks[j] = s.tasks[j], s.tasks[i]
 }
 
 func (s byPriorityAndDate) Less(i, j int) bool {
-	taskA := s[i]
-	taskB := s[j]
+	taskA := s.tasks[i]
+	taskB := s.tasks[j]
 
 	priorityA := taskA.PriorityValue()
 	priorityB := taskB.PriorityValue()
@@ -58,6 +120,16 @@ func (s byPriorityAndDate) Less(i, j int) bool {
 		// Высший приоритет идет раньше (High=3 > Medium=2 > Low=1)
 		return priorityA > priorityB
 	}
+
+	if s.topoRank != nil {
+		rankA, okA := s.topoRank[taskA.ID]
+		rankB, okB := s.topoRank[taskB.ID]
+		if okA && okB && rankA != rankB {
+			// В пределах приоритета зависимости выводятся раньше зависимых от них задач
+			return rankA < rankB
+		}
+	}
+
 	// При равных приоритетах, более ранняя дата создания идет раньше
 	return taskA.CreatedAt.Before(taskB.CreatedAt)
 }
@@ -78,32 +150,106 @@ func main() {
 	}()
 
 	taskPath := filepath.Join(storageDir, tasksFileName)
+	walDir := filepath.Join(storageDir, walDirName)
 	archiverPath := filepath.Join(storageDir, archiverFileName)
 	logPath := filepath.Join(storageDir, logFileName)
+	resultsDir := filepath.Join(storageDir, resultsDirName)
+	archiveResultsDir := filepath.Join(storageDir, archiveResultsDirName)
 
 	if err := os.MkdirAll(storageDir, 0755); err != nil {
 		fmt.Fprintf(os.Stderr, "Ошибка создания директории '%s' для хранения задач: %v\n", storageDir, err)
 		os.Exit(1)
 	}
 
+	// Задаем флаги и читаем их с консоли. Разбираем их тут, до инициализации
+	// логгера и остальных компонентов, т.к. logBlockingFlag нужен уже
+	// конструктору логгера (logger.NewLoggerWithOptions), а не может быть
+	// применен к уже созданному *Logger.
+	addFlag := flag.String("add", "", "Добавить задачу")
+	listFlag := flag.Bool("list", false, "Показать список задач")
+	doneFlag := flag.Int("done", 0, "Отметить задачу как выполненную")
+	deleteFlag := flag.Int ("delete", 0, "Удалить задачу")
+	archivedFlag := flag.Bool("archived", false, "Показать заархивированные задачи")
+	restoreFlag := flag.Int("restore", 0, "Восстановить заархивированную задачу по ID")
+	purgeArchivedFlag := flag.Bool("purge-archived", false, "Удалить все заархивированные задачи")
+	noteFlag := flag.Int("note", 0, "Прикрепить к задаче результат/заметку, прочитанную из stdin")
+	showFlag := flag.Int("show", 0, "Показать прикрепленный результат/заметку задачи по ID")
+	verifyFlag := flag.Bool("verify", false, "Проверить контрольную сумму файла задач")
+
+	priorityFlag := flag.String("priority", defaultPriority, "Приоритет задачи (low, medium, high)")
+	retentionFlag := flag.Duration("retention", 0, "Срок хранения задачи в архиве после завершения, напр. 72h (0 = бессрочно)")
+	depsFlag := flag.String("deps", "", "ID задач-зависимостей через запятую (используется вместе с -add)")
+	readyFlag := flag.Bool("ready", false, "Показать только задачи, готовые к выполнению (все зависимости завершены)")
+	writebackFlag := flag.Duration("writeback", 0, "Период фонового сброса кэша задач на диск, напр. 5s (0 = синхронная запись при каждой мутации, как раньше)")
+	auditFlag := flag.Int("audit", -1, "Показать последние N событий аудита из кольцевого буфера, напр. -audit 20 (0 = показать все, что есть в буфере)")
+	watchFlag := flag.Duration("watch", 0, "Период опроса файла задач на внешние изменения, напр. 2s (0 = отключено)")
+	logBlockingFlag := flag.Bool("log-blocking", false, "Блокировать вызывающего при переполнении буфера логов вместо потери сообщений (logger.LogModeBlocking)")
+	flag.Parse()
+
+	logMode := logger.LogModeNonBlock
+	if *logBlockingFlag {
+		logMode = logger.LogModeBlocking
+	}
+
 	var logErr error
-	appLogger, logErr = logger.NewLogger(logPath, logBuffer, &wg)
+	appLogger, logErr = logger.NewLoggerWithOptions(logPath, logBuffer, &loggerWG, logger.LoggerOptions{
+		Mode:   logMode,
+		Rotate: defaultLogRotateConfig,
+	})
 	if logErr != nil {
 		fmt.Fprintf(os.Stderr, "Ошибка создания логгера: %v\n", logErr)
 		os.Exit(1)
 	}
 
-	// Запускаем логгер
-	appLogger.Run(ctx)
+	// Запускаем логгер на фоновом context.Background(), а не на общем
+	// отменяемом ctx: если бы логгер слушал тот же ctx.Done(), он закрылся бы
+	// в момент прихода сигнала - раньше, чем успеют дологгироваться все
+	// сообщения доархивации и финального чекпоинта ниже по функции (именно
+	// эта гонка и роняла их молча). Явный appLogger.Shutdown(...) в конце
+	// main - единственное, что теперь останавливает горутину логгера.
+	appLogger.Run(context.Background())
 
 	appLogger.Log("Приложение запускается")
 
-	// Загружаем задачи из файла
+	// eventBus дополняет appLogger типизированными доменными событиями (см.
+	// pkg/events) - appLogger остается основным каналом строковых сообщений,
+	// а eventBus - для событий, которые потребители вроде `taskm -audit`
+	// обрабатывают независимо, не блокируя друг друга.
+	eventsPath := filepath.Join(storageDir, eventsFileName)
+	eventBus = events.NewBus()
+	eventBus.Run(ctx, &wg)
+
+	fileSub, fileSubErr := events.NewFileSubscriber(eventsPath)
+	if fileSubErr != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка создания файлового подписчика событий '%s': %v\n", eventsPath, fileSubErr)
+		appLogger.Log(fmt.Sprintf("Ошибка создания файлового подписчика событий '%s': %v", eventsPath, fileSubErr))
+	} else {
+		fileSub.Run(ctx, eventBus, &wg)
+	}
+
+	auditRing = events.NewRingBufferSubscriber(auditRingSize)
+	auditRing.Run(ctx, eventBus, &wg)
+
+	// taskStore оборачивает taskPath через Backend и дает WAL-бэкап мутаций
+	// между чекпоинтами (см. Store.EnableWAL) - сам файл tasks.txt по-прежнему
+	// читается/пишется через Store.Load/Save (атомарная tmp+rename запись).
+	taskStore = storage.NewStore(storage.OSBackend{}, taskPath)
+	if err := taskStore.EnableWAL(walDir, storage.WALConfig{}); err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка включения WAL в '%s': %v\n", walDir, err)
+		appLogger.Log(fmt.Sprintf("Ошибка включения WAL в '%s': %v", walDir, err))
+		os.Exit(1)
+	}
+
+	// Загружаем задачи из файла: Recover читает последний снэпшот tasks.txt и
+	// доигрывает поверх него мутации из WAL, случившиеся после последнего
+	// Checkpoint - это восстанавливает мутации, не попавшие в снэпшот из-за
+	// аварийного завершения между чекпоинтами.
 	var loadErr error
 	var maxID int
-	tasks, maxID, loadErr = storage.LoadTasks(taskPath)
+	tasks, maxID, loadErr = storage.Recover(taskPath, walDir)
 	if loadErr != nil {
 		appLogger.Log(fmt.Sprintf("Ошибка загрузки задач %v:", loadErr))
+		eventBus.Publish(events.NewEvent(events.KindStoreCorrupted, loadErr))
 		tasks = []task.Task{}
 	} else {
 		appLogger.Log(fmt.Sprintf("Загружено %d задач. Максимальный ID задачи: %d", len(tasks), maxID))
@@ -111,22 +257,73 @@ func main() {
 
 	idGen = utils.NewIDGenerator(maxID)
 
-	appArchiver = archiver.NewArchiver(archiverPath, &tasks, &taskMutex, &wg)
+	resultRegistry = result.NewRegistry(resultsDir)
+	archiveResultRegistry = result.NewRegistry(archiveResultsDir)
+
+	// archiveFileMutex - общий для appArchiver и appInspector, т.к. оба
+	// читают-изменяют-перезаписывают один и тот же archiverPath: Archiver
+	// дописывает в него по тику (см. archiverInterval), а Inspector
+	// перезаписывает его по CLI-командам -restore/-delete/-purge-archived -
+	// без общего мьютекса это гонка на одном файле.
+	appArchiver = archiver.NewArchiverWithOptions(archiverPath, &tasks, &taskMutex, &archiveFileMutex, &wg, defaultArchiveRotateConfig, resultRegistry, archiveResultRegistry)
 	appArchiver.Run(ctx, archiverInterval)
 
-	// Задаем флаги и читаем их с консоли
-	addFlag := flag.String("add", "", "Добавить задачу")
-	listFlag := flag.Bool("list", false, "Показать список задач")
-	doneFlag := flag.Int("done", 0, "Отметить задачу как выполненную")
-	deleteFlag := flag.Int ("delete", 0, "Удалить задачу")
+	appInspector = inspector.NewInspectorWithOptions(archiverPath, &tasks, &taskMutex, &archiveFileMutex, idGen, resultRegistry, archiveResultRegistry)
 
-	priorityFlag := flag.String("priority", defaultPriority, "Приоритет задачи (low, medium, high)")
-	flag.Parse()
+	// При -watch>0 внешние правки tasks.txt (сделанные, например, другим
+	// процессом или руками в редакторе) подмешиваются в живой tasks через
+	// Store.OnReload, не дожидаясь следующего запуска - см. mergeExternalReload.
+	if *watchFlag > 0 {
+		if err := taskStore.EnableWatcher(*watchFlag, eventBus); err != nil {
+			fmt.Fprintf(os.Stderr, "Ошибка включения слежения за файлом задач: %v\n", err)
+			appLogger.Log(fmt.Sprintf("Ошибка включения слежения за файлом задач: %v", err))
+			os.Exit(1)
+		}
+		taskStore.OnReload(mergeExternalReload)
+		taskStore.RunWatcher(ctx, &wg)
+	}
+
+	// При -writeback>0 мутации (add/done/delete) идут через writebackStore,
+	// который держит задачи в памяти и сбрасывает их на диск раз в
+	// writebackFlag вместо немедленной перезаписи tasks.txt на каждую
+	// мутацию (см. pkg/storage/writeback.go). Чекпоинтим WAL в снэпшот перед
+	// тем, как writebackStore загрузит его, иначе мутации, восстановленные
+	// только что из WAL, не попали бы в его стартовый снимок.
+	if *writebackFlag > 0 {
+		if err := taskStore.Checkpoint(tasks); err != nil {
+			fmt.Fprintf(os.Stderr, "Ошибка чекпоинта перед включением writeback-кэша: %v\n", err)
+			appLogger.Log(fmt.Sprintf("Ошибка чекпоинта перед включением writeback-кэша: %v", err))
+			os.Exit(1)
+		}
+
+		var wbErr error
+		writebackStore, wbErr = storage.NewWritebackStore(taskStore, *writebackFlag)
+		if wbErr != nil {
+			fmt.Fprintf(os.Stderr, "Ошибка создания writeback-кэша: %v\n", wbErr)
+			appLogger.Log(fmt.Sprintf("Ошибка создания writeback-кэша: %v", wbErr))
+			os.Exit(1)
+		}
+		writebackStore.Run(ctx, &wg)
+
+		appArchiver.OnArchived(func(archived []task.Task) {
+			for _, t := range archived {
+				if err := writebackStore.Delete(t.ID); err != nil {
+					appLogger.Log(fmt.Sprintf("Ошибка удаления заархивированной задачи %d из writeback-кэша: %v", t.ID, err))
+				}
+			}
+		})
+	}
+
+	dependsOn, depsErr := parseDependsOn(*depsFlag)
+	if depsErr != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка: неверный список зависимостей -deps: %v\n", depsErr)
+		os.Exit(1)
+	}
 
 	actionTaken := false
 	if *addFlag != "" {
 		actionTaken = true
-		handleAddTask(*addFlag, *priorityFlag)
+		handleAddTask(*addFlag, *priorityFlag, *retentionFlag, dependsOn)
 	}
 	if *listFlag {
 		if actionTaken {
@@ -135,7 +332,7 @@ func main() {
 			os.Exit(1)
 		}
 		actionTaken = true
-		handleListTasks()
+		handleListTasks(*readyFlag)
 	}
 	if *doneFlag != 0 {
 		if actionTaken {
@@ -155,10 +352,73 @@ func main() {
 		actionTaken = true
 		handleDeleteTask(*deleteFlag)
 	}
+	if *archivedFlag {
+		if actionTaken {
+			fmt.Fprintln(os.Stderr, "Ошибка: нельзя использовать одновременно флаги -archived с другими")
+			appLogger.Log ("Ошибка: Использование нескольких флагов")
+			os.Exit(1)
+		}
+		actionTaken = true
+		handleShowArchived()
+	}
+	if *restoreFlag != 0 {
+		if actionTaken {
+			fmt.Fprintln(os.Stderr, "Ошибка: нельзя использовать одновременно флаги -restore с другими")
+			appLogger.Log ("Ошибка: Использование нескольких флагов")
+			os.Exit(1)
+		}
+		actionTaken = true
+		handleRestoreTask(*restoreFlag)
+	}
+	if *purgeArchivedFlag {
+		if actionTaken {
+			fmt.Fprintln(os.Stderr, "Ошибка: нельзя использовать одновременно флаги -purge-archived с другими")
+			appLogger.Log ("Ошибка: Использование нескольких флагов")
+			os.Exit(1)
+		}
+		actionTaken = true
+		handlePurgeArchived()
+	}
+	if *noteFlag != 0 {
+		if actionTaken {
+			fmt.Fprintln(os.Stderr, "Ошибка: нельзя использовать одновременно флаги -note с другими")
+			appLogger.Log ("Ошибка: Использование нескольких флагов")
+			os.Exit(1)
+		}
+		actionTaken = true
+		handleNoteTask(*noteFlag, os.Stdin)
+	}
+	if *showFlag != 0 {
+		if actionTaken {
+			fmt.Fprintln(os.Stderr, "Ошибка: нельзя использовать одновременно флаги -show с другими")
+			appLogger.Log ("Ошибка: Использование нескольких флагов")
+			os.Exit(1)
+		}
+		actionTaken = true
+		handleShowResult(*showFlag)
+	}
+	if *verifyFlag {
+		if actionTaken {
+			fmt.Fprintln(os.Stderr, "Ошибка: нельзя использовать одновременно флаги -verify с другими")
+			appLogger.Log ("Ошибка: Использование нескольких флагов")
+			os.Exit(1)
+		}
+		actionTaken = true
+		handleVerify(taskPath)
+	}
+	if *auditFlag != -1 {
+		if actionTaken {
+			fmt.Fprintln(os.Stderr, "Ошибка: нельзя использовать одновременно флаги -audit с другими")
+			appLogger.Log ("Ошибка: Использование нескольких флагов")
+			os.Exit(1)
+		}
+		actionTaken = true
+		handleAudit(*auditFlag)
+	}
 
 	if !actionTaken && len(os.Args) >1 {
 		fmt.Fprintln(os.Stderr, "Ошибка: Не указаны флаги")
-		fmt.Fprintln(os.Stderr, "Использовать: -add, -list, -done, -delete")
+		fmt.Fprintln(os.Stderr, "Использовать: -add, -list, -done, -delete, -archived, -restore, -purge-archived, -ready, -deps, -note, -show, -verify, -audit")
 		appLogger.Log ("Ошибка: Не указаны флаги")
 		os.Exit(1)
 	}
@@ -194,7 +454,31 @@ func main() {
 
 	// блокируем таск чтобы записать
 	taskMutex.Lock()
-	err := storage.SaveTasks(taskPath, tasks)
+	policy := saveTasksRetryPolicy
+	policy.OnRetry = func(attempt int, retryErr error) {
+		logMsg := fmt.Sprintf("Попытка %d сохранения задач не удалась: %v", attempt, retryErr)
+		appLogger.Log(logMsg)
+		fmt.Fprintf(os.Stderr, "%s\n", logMsg)
+	}
+	// Используем отдельный context.Background(), а не основной ctx: на этом этапе
+	// ctx уже отменен (мы только что дождались ctx.Done()), и повторы не должны
+	// прерываться им же.
+	var err error
+	if writebackStore != nil {
+		// writebackStore.Run уже сделал финальный синхронный Sync по ctx.Done()
+		// (мы только что дождались его через wg.Wait() выше) - повторный
+		// Checkpoint тут перезаписал бы tasks.txt тем же содержимым вхолостую.
+		err = retry.Do(context.Background(), policy, func() error {
+			return writebackStore.Sync()
+		})
+	} else {
+		// Checkpoint атомарно перезаписывает tasks.txt и усекает WAL - мутации,
+		// записанные в WAL на протяжении работы приложения, больше не нужны для
+		// восстановления, т.к. теперь они отражены в этом снэпшоте.
+		err = retry.Do(context.Background(), policy, func() error {
+			return taskStore.Checkpoint(tasks)
+		})
+	}
 	// разблокируем таск
 	taskMutex.Unlock()
 	if err != nil {
@@ -203,27 +487,123 @@ func main() {
 		fmt.Fprintf(os.Stderr, "%s\n", logMsg)
 	} else {
 		appLogger.Log ("Задачи сохранены в файл")
+		eventBus.Publish(events.NewEvent(events.KindStoreFlushed, len(tasks)))
 		fmt.Println("Задачи сохранены в файл")
 	}
 
-	if appLogger != nil { // Добавим проверку на nil на всякий случай
-		appLogger.Close()
+	if err := taskStore.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка закрытия WAL: %v\n", err)
 	}
 
-	select {
-		case <-waitChan:
-			fmt.Println("Фоновые процессы завершены")
-		case <-time.After(waitTimeout):
-			fmt.Fprintf(os.Stderr, "Время ожидания фоновых процессов истекло")
+	// DroppedCount/WrittenCount делают потерю сообщений из-за переполнения
+	// буфера логов (в LogModeNonBlock) видимой на выходе, а не только
+	// обнаруживаемой по строкам "[logger] dropped N messages" внутри
+	// самого лог-файла.
+	fmt.Printf("Логгер: записано %d, потеряно %d сообщений.\n", appLogger.WrittenCount(), appLogger.DroppedCount())
+
+	// Shutdown дочитывает то, что уже скопилось в канале логгера (включая
+	// сообщения, записанные выше по ходу этой же функции), на диск в пределах
+	// shutdownTimeout и только потом закрывает файл - в отличие от старого
+	// Close()+ctx.Done() он не помечает логгер закрытым до этого момента, так
+	// что финальные логи доархивации и чекпоинта не теряются молча.
+	// InstallSignalHandler тут намеренно не используется: он выставил бы
+	// "закрыт" сразу по приходу сигнала, а само это место выполняется уже
+	// после сигнала, когда приложению еще нужно логировать весь процесс
+	// ожидания фоновых процессов и финального чекпоинта - т.е. он бы вернул
+	// ровно ту тихую потерю сообщений, которую Shutdown должен устранять.
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer shutdownCancel()
+	if err := appLogger.Shutdown(shutdownCtx); err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка graceful shutdown логгера: %v\n", err)
 	}
 
-	appLogger.Close()
 	fmt.Println("Приложение завершено")
 }
 
 // разбираемся с функциями для флагов
 
-func handleAddTask(title, priority string) {
+// parseDependsOn разбирает значение флага -deps ("1,2,3") в список ID.
+// Пустая строка означает отсутствие зависимостей.
+func parseDependsOn(raw string) ([]int, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	parts := strings.Split(raw, ",")
+	ids := make([]int, 0, len(parts))
+	for _, p := range parts {
+		id, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil {
+			return nil, fmt.Errorf("неверный ID зависимости '%s': %w", p, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// mergeExternalReload применяет изменения, обнаруженные Store.Watcher вовне
+// (другим процессом, правкой файла руками и т.п.), поверх живого среза
+// tasks, не теряя не сброшенные на диск мутации этого процесса - см.
+// Store.OnReload. Публикацию событий по этим изменениям уже делает сам
+// watcher (см. pkg/storage/watcher.go), здесь только отражаем их в памяти.
+func mergeExternalReload(added, removed, changed []task.Task) {
+	taskMutex.Lock()
+	defer taskMutex.Unlock()
+
+	byID := make(map[int]int, len(tasks))
+	for i, t := range tasks {
+		byID[t.ID] = i
+	}
+
+	for _, t := range added {
+		if _, ok := byID[t.ID]; ok {
+			continue
+		}
+		tasks = append(tasks, t)
+		idGen.UpdateGenerator(t.ID)
+		if writebackStore != nil {
+			if err := writebackStore.Add(t); err != nil {
+				appLogger.Log(fmt.Sprintf("Ошибка отражения внешне добавленной задачи %d в writeback-кэше: %v", t.ID, err))
+			}
+		}
+	}
+
+	for _, t := range changed {
+		if i, ok := byID[t.ID]; ok {
+			tasks[i] = t
+		}
+		if writebackStore != nil {
+			if err := writebackStore.Edit(t); err != nil {
+				appLogger.Log(fmt.Sprintf("Ошибка отражения внешне измененной задачи %d в writeback-кэше: %v", t.ID, err))
+			}
+		}
+	}
+
+	if len(removed) > 0 {
+		removedIDs := make(map[int]struct{}, len(removed))
+		for _, t := range removed {
+			removedIDs[t.ID] = struct{}{}
+		}
+		remaining := tasks[:0]
+		for _, t := range tasks {
+			if _, ok := removedIDs[t.ID]; ok {
+				continue
+			}
+			remaining = append(remaining, t)
+		}
+		tasks = remaining
+		if writebackStore != nil {
+			for id := range removedIDs {
+				if err := writebackStore.Delete(id); err != nil {
+					appLogger.Log(fmt.Sprintf("Ошибка отражения внешнего удаления задачи %d в writeback-кэше: %v", id, err))
+				}
+			}
+		}
+	}
+
+	appLogger.Log(fmt.Sprintf("Обнаружено внешнее изменение файла задач: +%d, -%d, ~%d", len(added), len(removed), len(changed)))
+}
+
+func handleAddTask(title, priority string, retention time.Duration, dependsOn []int) {
 	switch priority {
 	case task.PriorityHigh, task.PriorityMedium, task.PriorityLow:
 		// все норм, ничего не делаем
@@ -232,18 +612,26 @@ func handleAddTask(title, priority string) {
 		priority = defaultPriority
 	}
 
-	newTask := task.AddTask(idGen.NextID(), title, priority)
+	newTask := task.AddTaskWithOptions(idGen.NextID(), title, priority, retention, dependsOn)
 
 	taskMutex.Lock()
 	tasks = append(tasks, newTask)
+	if writebackStore != nil {
+		if err := writebackStore.Add(newTask); err != nil {
+			appLogger.Log(fmt.Sprintf("Ошибка добавления задачи %d в writeback-кэш: %v", newTask.ID, err))
+		}
+	} else if err := taskStore.AppendMutation(storage.WALOpAdd, newTask); err != nil {
+		appLogger.Log(fmt.Sprintf("Ошибка записи мутации добавления в WAL для задачи %d: %v", newTask.ID, err))
+	}
 	taskMutex.Unlock()
 
 	logMsg := fmt.Sprintf("Задача добавлена ID %d: \"%s\" приоритет: %s", newTask.ID, newTask.Title, newTask.Priority)
 	appLogger.Log(logMsg)
+	eventBus.Publish(events.NewEvent(events.KindTaskAdded, newTask))
 	fmt.Printf("Task added with ID %d.\n", newTask.ID)
 }
 
-func handleListTasks() {
+func handleListTasks(readyOnly bool) {
 	taskMutex.Lock()
 	// Создаем копию для сортировки, чтобы быстро освободить мьютекс
 	tasksCopy := make([]task.Task, len(tasks))
@@ -256,8 +644,38 @@ func handleListTasks() {
 		return
 	}
 
-	
-	sort.Sort(byPriorityAndDate(tasksCopy))
+	graph, graphErr := dag.BuildGraph(tasksCopy)
+	if graphErr != nil {
+		fmt.Fprintf(os.Stderr, "Внимание: граф зависимостей не построен: %v\n", graphErr)
+		appLogger.Log(fmt.Sprintf("Ошибка построения графа зависимостей: %v", graphErr))
+	}
+
+	if readyOnly && graph != nil {
+		filtered := tasksCopy[:0:0]
+		for _, t := range tasksCopy {
+			if !t.Done && graph.Ready(t.ID) {
+				filtered = append(filtered, t)
+			}
+		}
+		tasksCopy = filtered
+	}
+
+	if len(tasksCopy) == 0 {
+		fmt.Println("No tasks found.")
+		appLogger.Log("Listed tasks: None found.")
+		return
+	}
+
+	var topoRank map[int]int
+	if graph != nil {
+		order := graph.TopoOrder()
+		topoRank = make(map[int]int, len(order))
+		for i, id := range order {
+			topoRank[id] = i
+		}
+	}
+
+	sort.Sort(byPriorityAndDate{tasks: tasksCopy, topoRank: topoRank})
 
 
 	fmt.Println("-------------------- TASKS --------------------")
@@ -284,6 +702,12 @@ func handleDoneTask(id int) {
 	taskMutex.Lock() // Блокируем перед доступом к tasks
 	defer taskMutex.Unlock() // Гарантируем разблокировку при выходе
 
+	graph, graphErr := dag.BuildGraph(tasks)
+	if graphErr != nil {
+		fmt.Fprintf(os.Stderr, "Внимание: граф зависимостей не построен: %v\n", graphErr)
+		appLogger.Log(fmt.Sprintf("Ошибка построения графа зависимостей: %v", graphErr))
+	}
+
 	found := false
 	for i := range tasks { // Используем индекс для модификации
 		if tasks[i].ID == id {
@@ -292,10 +716,26 @@ func handleDoneTask(id int) {
 				appLogger.Log(fmt.Sprintf("Attempted to mark task ID %d as done, but it was already done.", id))
 				return // Выходим из функции, так как задача уже выполнена
 			}
+			if graph != nil {
+				if blocking := graph.BlockingDeps(id); len(blocking) > 0 {
+					fmt.Fprintf(os.Stderr, "Error: Task %d заблокирована незавершенными зависимостями: %v\n", id, blocking)
+					appLogger.Log(fmt.Sprintf("Отказано в завершении задачи ID %d - есть незавершенные зависимости: %v", id, blocking))
+					return
+				}
+			}
 			tasks[i].Done = true // Модифицируем задачу в срезе
+			tasks[i].CompletedAt = time.Now()
 			found = true
+			if writebackStore != nil {
+				if err := writebackStore.Complete(tasks[i].ID); err != nil {
+					appLogger.Log(fmt.Sprintf("Ошибка отметки задачи %d завершенной в writeback-кэше: %v", tasks[i].ID, err))
+				}
+			} else if err := taskStore.AppendMutation(storage.WALOpComplete, tasks[i]); err != nil {
+				appLogger.Log(fmt.Sprintf("Ошибка записи мутации завершения в WAL для задачи %d: %v", tasks[i].ID, err))
+			}
 			logMsg := fmt.Sprintf("Marked task ID %d as done: \"%s\"", tasks[i].ID, tasks[i].Title)
 			appLogger.Log(logMsg)
+			eventBus.Publish(events.NewEvent(events.KindTaskCompleted, tasks[i]))
 			fmt.Printf("Marked task with ID %d as done.\n", id)
 			break // Выходим из цикла, так как нашли и обработали задачу
 		}
@@ -328,11 +768,170 @@ func handleDeleteTask(id int) {
 		// tasks[len(tasks)-1] = nil // Опционально: обнуляем последний элемент для сборщика мусора (для срезов указателей) - здесь не строго нужно
 		tasks = tasks[:len(tasks)-1]            // Уменьшаем длину среза на 1
 
+		if writebackStore != nil {
+			if err := writebackStore.Delete(id); err != nil {
+				appLogger.Log(fmt.Sprintf("Ошибка удаления задачи %d из writeback-кэша: %v", id, err))
+			}
+		} else if err := taskStore.AppendDeleteMutation(id); err != nil {
+			appLogger.Log(fmt.Sprintf("Ошибка записи мутации удаления в WAL для задачи %d: %v", id, err))
+		}
+
 		logMsg := fmt.Sprintf("Deleted task ID %d: \"%s\"", id, deletedTaskTitle)
 		appLogger.Log(logMsg)
+		eventBus.Publish(events.NewEvent(events.KindTaskDeleted, id))
 		fmt.Printf("Deleted task with ID %d.\n", id)
 	} else {
 		fmt.Fprintf(os.Stderr, "Error: Task with ID %d not found for deletion.\n", id)
 		appLogger.Log(fmt.Sprintf("Error: Failed to delete task ID %d - not found.", id))
 	}
+}
+
+func handleShowArchived() {
+	archived, err := appInspector.ListArchived(inspector.Filter{})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка: не удалось прочитать архив: %v\n", err)
+		appLogger.Log(fmt.Sprintf("Ошибка чтения архива: %v", err))
+		os.Exit(1)
+	}
+
+	if len(archived) == 0 {
+		fmt.Println("No archived tasks found.")
+		appLogger.Log("Listed archived tasks: None found.")
+		return
+	}
+
+	fmt.Println("-------------------- ARCHIVED TASKS --------------------")
+	for _, t := range archived {
+		createdStr := t.CreatedAt.Format("2006-01-02 15:04")
+		fmt.Printf("  ID: %-4d | Priority: %-6s | Created: %s | Title: %s\n", t.ID, t.Priority, createdStr, t.Title)
+	}
+	fmt.Println("----------------------------------------------------------")
+	appLogger.Log(fmt.Sprintf("Listed %d archived tasks.", len(archived)))
+}
+
+func handleRestoreTask(id int) {
+	if err := appInspector.RestoreArchivedTask(id); err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка: не удалось восстановить задачу %d: %v\n", id, err)
+		appLogger.Log(fmt.Sprintf("Ошибка восстановления задачи %d: %v", id, err))
+		os.Exit(1)
+	}
+	fmt.Printf("Restored task with ID %d.\n", id)
+	appLogger.Log(fmt.Sprintf("Restored task ID %d from archive.", id))
+}
+
+func handleNoteTask(id int, stdin io.Reader) {
+	data, err := io.ReadAll(stdin)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка: не удалось прочитать заметку из stdin: %v\n", err)
+		appLogger.Log(fmt.Sprintf("Ошибка чтения заметки из stdin для задачи %d: %v", id, err))
+		os.Exit(1)
+	}
+
+	taskMutex.Lock() // Блокируем перед доступом к tasks
+	defer taskMutex.Unlock() // Гарантируем разблокировку при выходе
+
+	var target *task.Task
+	for i := range tasks {
+		if tasks[i].ID == id {
+			target = &tasks[i]
+			break
+		}
+	}
+
+	if target == nil {
+		fmt.Fprintf(os.Stderr, "Error: Task with ID %d not found.\n", id)
+		appLogger.Log(fmt.Sprintf("Error: Failed to attach note to task ID %d - not found.", id))
+		os.Exit(1)
+	}
+
+	// Write мутирует target.HasNotes, поэтому должен выполняться под тем же
+	// taskMutex, что и остальные обращения к срезу tasks - иначе эта запись
+	// гоняется с архиватором и другими handle*Task, держащими лок на всю
+	// мутацию (см. handleDoneTask, handleDeleteTask).
+	if _, err := target.ResultWriter(resultRegistry).Write(data); err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка: не удалось сохранить заметку задачи %d: %v\n", id, err)
+		appLogger.Log(fmt.Sprintf("Ошибка сохранения заметки задачи %d: %v", id, err))
+		os.Exit(1)
+	}
+
+	fmt.Printf("Attached %d byte(s) of notes to task ID %d.\n", len(data), id)
+	appLogger.Log(fmt.Sprintf("Attached %d byte(s) of notes to task ID %d.", len(data), id))
+}
+
+func handleShowResult(id int) {
+	taskMutex.Lock()
+	found := false
+	for _, t := range tasks {
+		if t.ID == id {
+			found = true
+			break
+		}
+	}
+	taskMutex.Unlock()
+
+	reg := resultRegistry
+	if !found {
+		// Задача могла быть уже заархивирована - ищем ее заметку там
+		reg = archiveResultRegistry
+	}
+
+	data, err := reg.Read(id)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка: не удалось прочитать результат задачи %d: %v\n", id, err)
+		appLogger.Log(fmt.Sprintf("Ошибка чтения результата задачи %d: %v", id, err))
+		os.Exit(1)
+	}
+	if data == nil {
+		fmt.Printf("No notes found for task ID %d.\n", id)
+		appLogger.Log(fmt.Sprintf("Showed result for task ID %d: none found.", id))
+		return
+	}
+
+	fmt.Println("-------------------- RESULT --------------------")
+	fmt.Println(string(data))
+	fmt.Println("--------------------------------------------------")
+	appLogger.Log(fmt.Sprintf("Showed result for task ID %d.", id))
+}
+
+func handlePurgeArchived() {
+	count, err := appInspector.DeleteAllArchivedTasks()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка: не удалось очистить архив: %v\n", err)
+		appLogger.Log(fmt.Sprintf("Ошибка очистки архива: %v", err))
+		os.Exit(1)
+	}
+	fmt.Printf("Purged %d archived task(s).\n", count)
+	appLogger.Log(fmt.Sprintf("Purged %d archived task(s).", count))
+}
+
+// handleVerify проверяет контрольную сумму файла задач по path (см.
+// pkg/storage/integrity.go): пересчитывает sha256 и сверяет его с футером
+// "#sha256:<hex>" и companion-файлом ".sum". Файл без футера (записанный до
+// появления этой проверки) считается валидным.
+func handleVerify(path string) {
+	if err := storage.Verify(path); err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка: проверка целостности файла задач не пройдена: %v\n", err)
+		appLogger.Log(fmt.Sprintf("Проверка целостности файла задач не пройдена: %v", err))
+		eventBus.Publish(events.NewEvent(events.KindStoreCorrupted, err))
+		os.Exit(1)
+	}
+	fmt.Println("Файл задач цел, контрольная сумма совпадает.")
+	appLogger.Log("Проверка целостности файла задач пройдена успешно.")
+}
+
+// handleAudit выводит до n последних событий eventBus, накопленных в
+// auditRing (см. events.RingBufferSubscriber) - n<=0 означает "показать все,
+// что есть в буфере" (семантика RingBufferSubscriber.Dump).
+func handleAudit(n int) {
+	entries := auditRing.Dump(n)
+	if len(entries) == 0 {
+		fmt.Println("Нет событий аудита.")
+		appLogger.Log("Showed audit log: none found.")
+		return
+	}
+
+	for _, e := range entries {
+		fmt.Printf("[%s] %s: %v\n", e.Timestamp.Format("2006-01-02 15:04:05"), e.Kind, e.Payload)
+	}
+	appLogger.Log(fmt.Sprintf("Showed %d audit event(s).", len(entries)))
 }
\ No newline at end of file
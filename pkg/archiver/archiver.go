@@ -5,28 +5,82 @@ import (
 	"fmt"
 	"os"
 	"sync"
+	"taskm/pkg/logrotate"
+	"taskm/pkg/result"
+	"taskm/pkg/retry"
 	"taskm/pkg/storage"
 	"taskm/pkg/task"
 	"time"
 )
 
+// appendRetryPolicy - политика повторов для записи в архивный файл:
+// транзиентная ошибка ввода-вывода (временно заблокированный файл,
+// "диск полон" посреди ротации на другом процессе) не должна ронять тик архивации.
+var appendRetryPolicy = retry.Policy{
+	MaxAttempts: 3,
+	BaseDelay:   200 * time.Millisecond,
+	Factor:      2,
+	MaxDelay:    2 * time.Second,
+}
+
 type Archiver struct {
-	archiverPath string
-	tasks        *[]task.Task
-	taskMutex    *sync.Mutex
-	wg           *sync.WaitGroup
+	archiverPath   string
+	tasks          *[]task.Task
+	taskMutex      *sync.Mutex
+	wg             *sync.WaitGroup
+	fileMutex      *sync.Mutex
+	rotator        *logrotate.Rotator
+	results        *result.Registry  // реестр sidecar-файлов активных задач (может быть nil)
+	archiveResults *result.Registry  // реестр sidecar-файлов в архиве (может быть nil)
+	onArchived     func([]task.Task) // см. OnArchived
 }
 
 // делаем что-то типа конструктора из ООП для архива
-func NewArchiver(archiverPath string, tasks *[]task.Task, taskMutex *sync.Mutex, wg *sync.WaitGroup) *Archiver {
+func NewArchiver(archiverPath string, tasks *[]task.Task, taskMutex *sync.Mutex, archiveMutex *sync.Mutex, wg *sync.WaitGroup) *Archiver {
+	return NewArchiverWithRotation(archiverPath, tasks, taskMutex, archiveMutex, wg, logrotate.RotateConfig{})
+}
+
+// NewArchiverWithRotation - то же самое, что и NewArchiver, но дополнительно
+// принимает политику ротации архивного файла (см. pkg/logrotate).
+func NewArchiverWithRotation(archiverPath string, tasks *[]task.Task, taskMutex *sync.Mutex, archiveMutex *sync.Mutex, wg *sync.WaitGroup, rotate logrotate.RotateConfig) *Archiver {
+	return NewArchiverWithOptions(archiverPath, tasks, taskMutex, archiveMutex, wg, rotate, nil, nil)
+}
+
+// NewArchiverWithOptions - то же самое, что и NewArchiverWithRotation, но
+// дополнительно принимает реестры sidecar-файлов результатов задач (см.
+// pkg/result): results - для активных задач, archiveResults - для архива.
+// Любой из них может быть nil, тогда копирование sidecar-файлов при
+// архивации отключено.
+//
+// archiveMutex должен быть тем же *sync.Mutex, что передается
+// inspector.NewInspectorWithOptions для того же archiverPath: Archiver
+// дописывает в этот файл по тику, а Inspector читает-изменяет-перезаписывает
+// его по командам CLI, и без общего мьютекса это гонка на одном и том же
+// файле (например, DeleteAllArchivedTasks может перезаписать файл пустым
+// списком, затерев только что дописанные Archiver'ом задачи).
+func NewArchiverWithOptions(archiverPath string, tasks *[]task.Task, taskMutex *sync.Mutex, archiveMutex *sync.Mutex, wg *sync.WaitGroup, rotate logrotate.RotateConfig, results *result.Registry, archiveResults *result.Registry) *Archiver {
 	return &Archiver{
-		archiverPath: archiverPath,
-		tasks:        tasks,
-		taskMutex:    taskMutex,
-		wg:           wg,
+		archiverPath:   archiverPath,
+		tasks:          tasks,
+		taskMutex:      taskMutex,
+		wg:             wg,
+		fileMutex:      archiveMutex,
+		rotator:        logrotate.NewRotator(archiverPath, rotate),
+		results:        results,
+		archiveResults: archiveResults,
 	}
 }
 
+// OnArchived регистрирует колбэк, вызываемый после того, как archiveCompletedTasks
+// успешно дописал задачи в архив и убрал их из живого среза (см.
+// removeArchivedFromLive). Нужен внешним кэшам перед *a.tasks (например,
+// storage.WritebackStore), которые иначе продолжили бы считать уже
+// заархивированные задачи живыми и переписывали бы их обратно при следующем
+// Sync.
+func (a *Archiver) OnArchived(fn func(archived []task.Task)) {
+	a.onArchived = fn
+}
+
 func (a *Archiver) Run(ctx context.Context, interval time.Duration){
 	a.wg.Add(1)
 	go func(){
@@ -39,10 +93,13 @@ func (a *Archiver) Run(ctx context.Context, interval time.Duration){
 			select {
 			case <- ticker.C:
 				fmt.Println("Тик архивации.")
-				err := a.archiveCompletedTasks()
+				err := a.archiveCompletedTasks(ctx)
 				if err != nil {
 					fmt.Fprintf(os.Stderr, "Ошибка архивации: %v\n", err)
 				}
+				if err := a.expireArchivedTasks(); err != nil {
+					fmt.Fprintf(os.Stderr, "Ошибка истечения срока хранения архива: %v\n", err)
+				}
 			case <- ctx.Done():
 				fmt.Println("Контекст архивации отменен. Остановка.")
 				fmt.Println("Горутина архивации остановлена.")
@@ -52,7 +109,7 @@ func (a *Archiver) Run(ctx context.Context, interval time.Duration){
 	}()
 }
 
-func (a *Archiver) archiveCompletedTasks() error {
+func (a *Archiver) archiveCompletedTasks(ctx context.Context) error {
 	a.taskMutex.Lock()
 	tasksToArchive := []task.Task{}
 	for _, t := range *a.tasks {
@@ -68,14 +125,104 @@ func (a *Archiver) archiveCompletedTasks() error {
 
 	fmt.Printf("Архивируем %d завершенных задач.\n", len(tasksToArchive))
 
-	err := storage.AppendTask(a.archiverPath, tasksToArchive)
+	// Берем file-мьютекс, чтобы ротация и запись в archiverPath не
+	// перемежались с другими обращениями к этому же файлу.
+	a.fileMutex.Lock()
+	defer a.fileMutex.Unlock()
+
+	if rotated, err := a.rotator.MaybeRotate(storage.TaskLinesSize(tasksToArchive)); err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка ротации архивного файла %s: %v\n", a.archiverPath, err)
+	} else if rotated {
+		fmt.Printf("Архиватор: файл %s ротирован.\n", a.archiverPath)
+	}
+
+	policy := appendRetryPolicy
+	policy.OnRetry = func(attempt int, err error) {
+		fmt.Fprintf(os.Stderr, "Архиватор: попытка %d записи в архив %s не удалась: %v\n", attempt, a.archiverPath, err)
+	}
+	err := retry.Do(ctx, policy, func() error {
+		return storage.AppendTask(a.archiverPath, tasksToArchive)
+	})
 	if err != nil {
 		return fmt.Errorf("Не удалось добавить таски в архив %s: %w", a.archiverPath, err)
 	}
 
 	fmt.Printf("Архиватор: Успешно добавлено %d тасок в %s.\n", len(tasksToArchive), a.archiverPath)
 
-	
+	if a.results != nil && a.archiveResults != nil {
+		for _, t := range tasksToArchive {
+			if err := a.results.CopyTo(t.ID, a.archiveResults); err != nil {
+				fmt.Fprintf(os.Stderr, "Архиватор: не удалось скопировать sidecar-файл задачи %d: %v\n", t.ID, err)
+			}
+		}
+	}
+
+	a.removeArchivedFromLive(tasksToArchive)
+
+	if a.onArchived != nil {
+		a.onArchived(tasksToArchive)
+	}
+
 	return nil
 
+}
+
+// removeArchivedFromLive убирает из *a.tasks задачи, только что успешно
+// дописанные в архив - иначе они остались бы в Done-статусе в живом списке
+// и попадали бы в archive.txt повторно на каждом следующем тике.
+func (a *Archiver) removeArchivedFromLive(archived []task.Task) {
+	archivedIDs := make(map[int]struct{}, len(archived))
+	for _, t := range archived {
+		archivedIDs[t.ID] = struct{}{}
+	}
+
+	a.taskMutex.Lock()
+	defer a.taskMutex.Unlock()
+	remaining := (*a.tasks)[:0]
+	for _, t := range *a.tasks {
+		if _, ok := archivedIDs[t.ID]; ok {
+			continue
+		}
+		remaining = append(remaining, t)
+	}
+	*a.tasks = remaining
+}
+
+// expireArchivedTasks удаляет из архива задачи, чей CompletedAt+Retention
+// уже в прошлом, и атомарно перезаписывает archiverPath.
+func (a *Archiver) expireArchivedTasks() error {
+	a.fileMutex.Lock()
+	defer a.fileMutex.Unlock()
+
+	archived, _, err := storage.LoadTasks(a.archiverPath)
+	if err != nil {
+		return fmt.Errorf("не удалось прочитать архив %s для проверки срока хранения: %w", a.archiverPath, err)
+	}
+
+	now := time.Now()
+	kept := make([]task.Task, 0, len(archived))
+	expiredCount := 0
+	for _, t := range archived {
+		if t.Retention > 0 && !t.CompletedAt.IsZero() && now.After(t.CompletedAt.Add(t.Retention)) {
+			expiredCount++
+			if a.archiveResults != nil {
+				if err := a.archiveResults.Delete(t.ID); err != nil {
+					fmt.Fprintf(os.Stderr, "Архиватор: не удалось удалить sidecar-файл задачи %d по истечении срока хранения: %v\n", t.ID, err)
+				}
+			}
+			continue
+		}
+		kept = append(kept, t)
+	}
+
+	if expiredCount == 0 {
+		return nil
+	}
+
+	if err := storage.SaveTasks(a.archiverPath, kept); err != nil {
+		return fmt.Errorf("не удалось перезаписать архив %s после истечения срока хранения: %w", a.archiverPath, err)
+	}
+
+	fmt.Printf("Архиватор: удалено %d задач(и) по истечении срока хранения.\n", expiredCount)
+	return nil
 }
\ No newline at end of file
@@ -7,11 +7,14 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"taskm/pkg/inspector"
+	"taskm/pkg/logrotate"
+	"taskm/pkg/result"
 	"taskm/pkg/storage" // Нужно для чтения архива
 	"taskm/pkg/task"
+	"taskm/pkg/utils"
 	"testing"
 	"time"
-	"reflect"
 )
 
 // Хелпер для создания временного файла
@@ -32,9 +35,10 @@ func TestNewArchiver(t *testing.T) {
 	archivePath := filepath.Join(dir, "archive.txt")
 	var tasks []task.Task
 	var taskMutex sync.Mutex
+	var archiveMutex sync.Mutex
 	var wg sync.WaitGroup
 
-	archiver := NewArchiver(archivePath, &tasks, &taskMutex, &wg)
+	archiver := NewArchiver(archivePath, &tasks, &taskMutex, &archiveMutex, &wg)
 
 	if archiver == nil {
 		t.Fatal("NewArchiver returned nil")
@@ -48,6 +52,9 @@ func TestNewArchiver(t *testing.T) {
 	if archiver.taskMutex != &taskMutex {
 		t.Error("Task mutex not set correctly")
 	}
+	if archiver.fileMutex != &archiveMutex {
+		t.Error("Archive file mutex not set correctly")
+	}
 	if archiver.wg != &wg {
 		t.Error("WaitGroup not set correctly")
 	}
@@ -59,11 +66,12 @@ func TestArchiveCompletedTasks_NoTasks(t *testing.T) {
 	archivePath := createEmptyTempFile(t, dir, "archive_no_tasks.txt")
 	tasks := []task.Task{} // Пустой список задач
 	var taskMutex sync.Mutex
+	var archiveMutex sync.Mutex
 	var wg sync.WaitGroup // Не используется напрямую в этой функции, но нужен для NewArchiver
 
-	archiver := NewArchiver(archivePath, &tasks, &taskMutex, &wg)
+	archiver := NewArchiver(archivePath, &tasks, &taskMutex, &archiveMutex, &wg)
 
-	err := archiver.archiveCompletedTasks()
+	err := archiver.archiveCompletedTasks(context.Background())
 	if err != nil {
 		t.Fatalf("archiveCompletedTasks failed: %v", err)
 	}
@@ -86,11 +94,12 @@ func TestArchiveCompletedTasks_NoCompletedTasks(t *testing.T) {
 		task.AddTask(2, "Task 2", task.PriorityLow),  // Не завершена
 	}
 	var taskMutex sync.Mutex
+	var archiveMutex sync.Mutex
 	var wg sync.WaitGroup
 
-	archiver := NewArchiver(archivePath, &tasks, &taskMutex, &wg)
+	archiver := NewArchiver(archivePath, &tasks, &taskMutex, &archiveMutex, &wg)
 
-	err := archiver.archiveCompletedTasks()
+	err := archiver.archiveCompletedTasks(context.Background())
 	if err != nil {
 		t.Fatalf("archiveCompletedTasks failed: %v", err)
 	}
@@ -121,26 +130,26 @@ func TestArchiveCompletedTasks_WithCompletedTasks(t *testing.T) {
 	copy(initialTasksCopy, tasks)
 
 	var taskMutex sync.Mutex
+	var archiveMutex sync.Mutex
 	var wg sync.WaitGroup
 
-	archiver := NewArchiver(archivePath, &tasks, &taskMutex, &wg)
+	archiver := NewArchiver(archivePath, &tasks, &taskMutex, &archiveMutex, &wg)
 
-	err := archiver.archiveCompletedTasks()
+	err := archiver.archiveCompletedTasks(context.Background())
 	if err != nil {
 		t.Fatalf("archiveCompletedTasks failed: %v", err)
 	}
 
-	// Проверяем, что исходный список задач НЕ изменился (т.к. архиватор только копирует)
-	if len(tasks) != len(initialTasksCopy) {
-		t.Fatalf("Original tasks slice length changed from %d to %d", len(initialTasksCopy), len(tasks))
+	// Заархивированные задачи должны быть убраны из живого списка, иначе они
+	// попали бы в archive.txt повторно на следующем тике - остаться должна
+	// только незавершенная задача.
+	if len(tasks) != 1 {
+		t.Fatalf("expected 1 task left in the live slice after archiving, got %d", len(tasks))
 	}
-	for i := range tasks {
-		if !reflect.DeepEqual(tasks[i], initialTasksCopy[i]) {
-			t.Errorf("Original task at index %d was modified.\nExpected: %+v\nActual:   %+v", i, initialTasksCopy[i], tasks[i])
-		}
+	if tasks[0].ID != 2 {
+		t.Errorf("expected remaining live task to be ID 2 (Pending Task), got ID %d", tasks[0].ID)
 	}
 
-
 	// Проверяем содержимое архивного файла
 	// Используем storage.LoadTasks для удобства
 	archivedTasks, maxID, err := storage.LoadTasks(archivePath)
@@ -149,8 +158,8 @@ func TestArchiveCompletedTasks_WithCompletedTasks(t *testing.T) {
 	}
 
 	expectedArchivedTasks := []task.Task{
-		tasks[0], // Completed Task 1
-		tasks[2], // Completed Task 2
+		initialTasksCopy[0], // Completed Task 1
+		initialTasksCopy[2], // Completed Task 2
 	}
 	expectedMaxID := 3 // Максимальный ID среди заархивированных
 
@@ -179,8 +188,8 @@ func TestArchiveCompletedTasks_WithCompletedTasks(t *testing.T) {
 	content, _ := os.ReadFile(archivePath)
 	contentStr := string(content)
 	timeFormat := "2006-01-02 15:04:05"
-	expectedLine1 := fmt.Sprintf("%d|%s|%t|%s|%s", tasks[0].ID, tasks[0].Title, tasks[0].Done, tasks[0].CreatedAt.Format(timeFormat), tasks[0].Priority)
-	expectedLine3 := fmt.Sprintf("%d|%s|%t|%s|%s", tasks[2].ID, tasks[2].Title, tasks[2].Done, tasks[2].CreatedAt.Format(timeFormat), tasks[2].Priority)
+	expectedLine1 := fmt.Sprintf("%d|%s|%t|%s|%s", initialTasksCopy[0].ID, initialTasksCopy[0].Title, initialTasksCopy[0].Done, initialTasksCopy[0].CreatedAt.Format(timeFormat), initialTasksCopy[0].Priority)
+	expectedLine3 := fmt.Sprintf("%d|%s|%t|%s|%s", initialTasksCopy[2].ID, initialTasksCopy[2].Title, initialTasksCopy[2].Done, initialTasksCopy[2].CreatedAt.Format(timeFormat), initialTasksCopy[2].Priority)
 
 	if !strings.Contains(contentStr, expectedLine1) {
 		t.Errorf("Archive content missing expected line: %s", expectedLine1)
@@ -194,15 +203,88 @@ func TestArchiveCompletedTasks_WithCompletedTasks(t *testing.T) {
 }
 
 
+func TestArchiveCompletedTasks_RepeatedTicksDoNotDuplicate(t *testing.T) {
+	dir := t.TempDir()
+	archivePath := createEmptyTempFile(t, dir, "archive_repeated.txt")
+
+	tasks := []task.Task{
+		{ID: 1, Title: "Completed Task", Done: true, CreatedAt: time.Now(), Priority: task.PriorityHigh},
+	}
+	var taskMutex sync.Mutex
+	var archiveMutex sync.Mutex
+	var wg sync.WaitGroup
+
+	archiver := NewArchiver(archivePath, &tasks, &taskMutex, &archiveMutex, &wg)
+
+	for i := 0; i < 3; i++ {
+		if err := archiver.archiveCompletedTasks(context.Background()); err != nil {
+			t.Fatalf("archiveCompletedTasks tick %d failed: %v", i, err)
+		}
+	}
+
+	if len(tasks) != 0 {
+		t.Errorf("expected the archived task to be removed from the live slice, got %d remaining", len(tasks))
+	}
+
+	archivedTasks, _, err := storage.LoadTasks(archivePath)
+	if err != nil {
+		t.Fatalf("failed to load archive: %v", err)
+	}
+	if len(archivedTasks) != 1 {
+		t.Errorf("expected exactly 1 archived entry after 3 ticks, got %d", len(archivedTasks))
+	}
+}
+
+// TestArchiveCompletedTasks_DeletedArchivedTaskDoesNotReappear защищает от
+// регресса: Archiver и Inspector делят один и тот же *tasks, и если бы
+// archiveCompletedTasks не убирал заархивированные задачи из живого списка,
+// следующий тик архивации заново дописал бы в archive.txt задачу, только что
+// удаленную оттуда Inspector.DeleteArchivedTask.
+func TestArchiveCompletedTasks_DeletedArchivedTaskDoesNotReappear(t *testing.T) {
+	dir := t.TempDir()
+	archivePath := createEmptyTempFile(t, dir, "archive_delete_then_tick.txt")
+
+	tasks := []task.Task{
+		{ID: 1, Title: "Completed Task", Done: true, CreatedAt: time.Now(), Priority: task.PriorityHigh},
+	}
+	var taskMutex sync.Mutex
+	var archiveMutex sync.Mutex
+	var wg sync.WaitGroup
+	idGen := utils.NewIDGenerator(1)
+
+	arc := NewArchiver(archivePath, &tasks, &taskMutex, &archiveMutex, &wg)
+	if err := arc.archiveCompletedTasks(context.Background()); err != nil {
+		t.Fatalf("archiveCompletedTasks failed: %v", err)
+	}
+
+	ins := inspector.NewInspector(archivePath, &tasks, &taskMutex, &archiveMutex, idGen)
+	if err := ins.DeleteArchivedTask(1); err != nil {
+		t.Fatalf("DeleteArchivedTask failed: %v", err)
+	}
+
+	if err := arc.archiveCompletedTasks(context.Background()); err != nil {
+		t.Fatalf("second archiveCompletedTasks failed: %v", err)
+	}
+
+	remaining, _, err := storage.LoadTasks(archivePath)
+	if err != nil {
+		t.Fatalf("LoadTasks failed: %v", err)
+	}
+	if len(remaining) != 0 {
+		t.Errorf("expected deleted task to stay gone, but archive has %+v", remaining)
+	}
+}
+
 // Тестирование Run сложно из-за тикера. Можно протестировать только запуск и остановку.
 func TestArchiver_Run_ContextCancel(t *testing.T) {
 	dir := t.TempDir()
 	archivePath := createEmptyTempFile(t, dir, "archive_run_cancel.txt")
 	tasks := []task.Task{}
 	var taskMutex sync.Mutex
+	var archiveMutex sync.Mutex
 	var wg sync.WaitGroup
 
-	archiver := NewArchiver(archivePath, &tasks, &taskMutex, &wg)
+	archiver := NewArchiver(archivePath, &tasks, &taskMutex, &archiveMutex, &wg)
 
 	ctx, cancel := context.WithCancel(context.Background())
 
@@ -236,4 +318,76 @@ func TestArchiver_Run_ContextCancel(t *testing.T) {
 	}
 
 	// Дополнительно можно проверить логи (если бы они были) или состояние
+}
+
+func TestArchiveCompletedTasks_CopiesResultSidecar(t *testing.T) {
+	dir := t.TempDir()
+	archivePath := createEmptyTempFile(t, dir, "archive_with_notes.txt")
+
+	tasks := []task.Task{
+		{ID: 1, Title: "Completed with note", Done: true, CreatedAt: time.Now(), Priority: task.PriorityHigh, HasNotes: true},
+	}
+	var taskMutex sync.Mutex
+	var archiveMutex sync.Mutex
+	var wg sync.WaitGroup
+
+	liveResults := result.NewRegistry(filepath.Join(dir, "results"))
+	archiveResults := result.NewRegistry(filepath.Join(dir, "archive-results"))
+	_, _ = liveResults.Writer(1).Write([]byte("investigated and fixed"))
+
+	archiver := NewArchiverWithOptions(archivePath, &tasks, &taskMutex, &archiveMutex, &wg, logrotate.RotateConfig{}, liveResults, archiveResults)
+
+	if err := archiver.archiveCompletedTasks(context.Background()); err != nil {
+		t.Fatalf("archiveCompletedTasks failed: %v", err)
+	}
+
+	data, err := archiveResults.Read(1)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if string(data) != "investigated and fixed" {
+		t.Errorf("expected sidecar copied with content %q, got %q", "investigated and fixed", string(data))
+	}
+}
+
+func TestExpireArchivedTasks_DropsExpiredOnly(t *testing.T) {
+	dir := t.TempDir()
+	archivePath := createEmptyTempFile(t, dir, "archive_expire.txt")
+
+	now := time.Now()
+	seedTasks := []task.Task{
+		{ID: 1, Title: "Expired", Done: true, CreatedAt: now, Priority: task.PriorityHigh,
+			CompletedAt: now.Add(-2 * time.Hour), Retention: time.Hour},
+		{ID: 2, Title: "Still fresh", Done: true, CreatedAt: now, Priority: task.PriorityLow,
+			CompletedAt: now.Add(-30 * time.Minute), Retention: time.Hour},
+		{ID: 3, Title: "Infinite retention", Done: true, CreatedAt: now, Priority: task.PriorityMedium,
+			CompletedAt: now.Add(-1000 * time.Hour), Retention: 0},
+	}
+	if err := storage.SaveTasks(archivePath, seedTasks); err != nil {
+		t.Fatalf("Failed to seed archive: %v", err)
+	}
+
+	tasks := []task.Task{}
+	var taskMutex sync.Mutex
+	var archiveMutex sync.Mutex
+	var wg sync.WaitGroup
+	archiver := NewArchiver(archivePath, &tasks, &taskMutex, &archiveMutex, &wg)
+
+	if err := archiver.expireArchivedTasks(); err != nil {
+		t.Fatalf("expireArchivedTasks failed: %v", err)
+	}
+
+	remaining, _, err := storage.LoadTasks(archivePath)
+	if err != nil {
+		t.Fatalf("Failed to load archive after expiry: %v", err)
+	}
+
+	if len(remaining) != 2 {
+		t.Fatalf("Expected 2 tasks to remain after expiry, got %d", len(remaining))
+	}
+	for _, rt := range remaining {
+		if rt.ID == 1 {
+			t.Errorf("Expected task ID 1 to be expired and removed, but it is still present")
+		}
+	}
 }
\ No newline at end of file
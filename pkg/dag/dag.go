@@ -0,0 +1,175 @@
+// Package dag строит граф зависимостей задач (task.Task.DependsOn), проверяет
+// его на циклы и вычисляет топологический порядок для вывода в режиме "ready".
+package dag
+
+import (
+	"fmt"
+	"sort"
+	"taskm/pkg/task"
+)
+
+// color - состояние вершины при обходе DFS для поиска циклов.
+type color int
+
+const (
+	white color = iota // еще не посещена
+	gray               // посещается сейчас (находится на стеке рекурсии)
+	black              // обход завершен
+)
+
+// CycleError сообщает, что граф зависимостей содержит цикл, и перечисляет
+// ID задач, образующих этот цикл.
+type CycleError struct {
+	IDs []int
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("обнаружен цикл в зависимостях задач: %v", e.IDs)
+}
+
+// Graph - граф зависимостей, построенный по полю DependsOn списка задач.
+type Graph struct {
+	nodes map[int]task.Task
+	edges map[int][]int // edges[id] = ID задач, от которых зависит id
+}
+
+// BuildGraph строит граф зависимостей по tasks и проверяет его на циклы.
+// Зависимости на несуществующие ID игнорируются (задача могла быть уже
+// заархивирована или удалена).
+func BuildGraph(tasks []task.Task) (*Graph, error) {
+	g := &Graph{
+		nodes: make(map[int]task.Task, len(tasks)),
+		edges: make(map[int][]int, len(tasks)),
+	}
+	for _, t := range tasks {
+		g.nodes[t.ID] = t
+	}
+	for _, t := range tasks {
+		for _, dep := range t.DependsOn {
+			if _, ok := g.nodes[dep]; ok {
+				g.edges[t.ID] = append(g.edges[t.ID], dep)
+			}
+		}
+	}
+
+	if cycle := g.findCycle(); cycle != nil {
+		return nil, &CycleError{IDs: cycle}
+	}
+
+	return g, nil
+}
+
+// findCycle обходит граф в глубину с раскраской вершин (white/gray/black):
+// попадание на серую вершину означает цикл. Возвращает ID задач цикла, либо
+// nil, если циклов нет.
+func (g *Graph) findCycle() []int {
+	colors := make(map[int]color, len(g.nodes))
+	ids := g.sortedIDs()
+
+	var stack []int
+	var visit func(id int) []int
+	visit = func(id int) []int {
+		colors[id] = gray
+		stack = append(stack, id)
+
+		for _, dep := range g.edges[id] {
+			switch colors[dep] {
+			case gray:
+				for i, sid := range stack {
+					if sid == dep {
+						return append(append([]int{}, stack[i:]...), dep)
+					}
+				}
+				return []int{dep}
+			case white:
+				if cyc := visit(dep); cyc != nil {
+					return cyc
+				}
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		colors[id] = black
+		return nil
+	}
+
+	for _, id := range ids {
+		if colors[id] == white {
+			if cyc := visit(id); cyc != nil {
+				return cyc
+			}
+		}
+	}
+	return nil
+}
+
+// TopoOrder возвращает ID задач в топологическом порядке (зависимости - перед
+// зависимыми от них задачами), вычисленном алгоритмом Кана. Из задач, готовых
+// к выводу в данный момент, первой всегда берется задача с наименьшим ID,
+// чтобы порядок был детерминированным.
+func (g *Graph) TopoOrder() []int {
+	inDegree := make(map[int]int, len(g.nodes))
+	for id := range g.nodes {
+		inDegree[id] = len(g.edges[id])
+	}
+
+	// dependents[dep] = список задач, которые зависят от dep
+	dependents := make(map[int][]int, len(g.nodes))
+	for id, deps := range g.edges {
+		for _, dep := range deps {
+			dependents[dep] = append(dependents[dep], id)
+		}
+	}
+
+	queue := []int{}
+	for _, id := range g.sortedIDs() {
+		if inDegree[id] == 0 {
+			queue = append(queue, id)
+		}
+	}
+
+	order := make([]int, 0, len(g.nodes))
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		order = append(order, id)
+
+		next := append([]int(nil), dependents[id]...)
+		sort.Ints(next)
+		for _, other := range next {
+			inDegree[other]--
+			if inDegree[other] == 0 {
+				queue = append(queue, other)
+			}
+		}
+		sort.Ints(queue)
+	}
+
+	return order
+}
+
+// BlockingDeps возвращает ID незавершенных зависимостей задачи id.
+func (g *Graph) BlockingDeps(id int) []int {
+	var blocking []int
+	for _, dep := range g.edges[id] {
+		if dt, ok := g.nodes[dep]; ok && !dt.Done {
+			blocking = append(blocking, dep)
+		}
+	}
+	sort.Ints(blocking)
+	return blocking
+}
+
+// Ready сообщает, завершены ли все зависимости задачи id.
+func (g *Graph) Ready(id int) bool {
+	return len(g.BlockingDeps(id)) == 0
+}
+
+func (g *Graph) sortedIDs() []int {
+	ids := make([]int, 0, len(g.nodes))
+	for id := range g.nodes {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
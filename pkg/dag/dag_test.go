@@ -0,0 +1,89 @@
+package dag
+
+import (
+	"errors"
+	"reflect"
+	"taskm/pkg/task"
+	"testing"
+)
+
+func TestBuildGraph_NoCycle(t *testing.T) {
+	tasks := []task.Task{
+		{ID: 1, Title: "A"},
+		{ID: 2, Title: "B", DependsOn: []int{1}},
+		{ID: 3, Title: "C", DependsOn: []int{1, 2}},
+	}
+
+	g, err := BuildGraph(tasks)
+	if err != nil {
+		t.Fatalf("BuildGraph failed: %v", err)
+	}
+
+	order := g.TopoOrder()
+	pos := make(map[int]int, len(order))
+	for i, id := range order {
+		pos[id] = i
+	}
+	if pos[1] > pos[2] || pos[2] > pos[3] {
+		t.Errorf("expected topo order 1,2,3, got %v", order)
+	}
+}
+
+func TestBuildGraph_Cycle(t *testing.T) {
+	tasks := []task.Task{
+		{ID: 1, Title: "A", DependsOn: []int{3}},
+		{ID: 2, Title: "B", DependsOn: []int{1}},
+		{ID: 3, Title: "C", DependsOn: []int{2}},
+	}
+
+	_, err := BuildGraph(tasks)
+	if err == nil {
+		t.Fatal("expected cycle error, got nil")
+	}
+	var cycleErr *CycleError
+	if !errors.As(err, &cycleErr) {
+		t.Fatalf("expected *CycleError, got %T: %v", err, err)
+	}
+	if len(cycleErr.IDs) == 0 {
+		t.Error("expected non-empty list of cyclic task IDs")
+	}
+}
+
+func TestBlockingDeps(t *testing.T) {
+	tasks := []task.Task{
+		{ID: 1, Title: "A", Done: false},
+		{ID: 2, Title: "B", Done: true},
+		{ID: 3, Title: "C", DependsOn: []int{1, 2}},
+	}
+
+	g, err := BuildGraph(tasks)
+	if err != nil {
+		t.Fatalf("BuildGraph failed: %v", err)
+	}
+
+	blocking := g.BlockingDeps(3)
+	if !reflect.DeepEqual(blocking, []int{1}) {
+		t.Errorf("expected [1] blocking task 3, got %v", blocking)
+	}
+
+	if g.Ready(3) {
+		t.Error("expected task 3 to not be ready while task 1 is incomplete")
+	}
+	if !g.Ready(2) {
+		t.Error("expected task 2 (no deps) to be ready")
+	}
+}
+
+func TestBuildGraph_IgnoresMissingDependency(t *testing.T) {
+	tasks := []task.Task{
+		{ID: 1, Title: "A", DependsOn: []int{999}},
+	}
+
+	g, err := BuildGraph(tasks)
+	if err != nil {
+		t.Fatalf("BuildGraph failed: %v", err)
+	}
+	if !g.Ready(1) {
+		t.Error("expected task with missing dependency to be considered ready")
+	}
+}
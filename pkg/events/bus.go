@@ -0,0 +1,154 @@
+// Package events предоставляет типизированную шину событий (Bus), на
+// которую можно подписаться (Subscribe) любым числом независимых
+// потребителей. Это дополнение к pkg/logger, а не его замена на месте:
+// logger.Logger остается основным каналом строковых сообщений для
+// cmd/taskmanager, а Bus предназначен для типизированных доменных событий
+// (добавление/завершение/удаление задачи, сброс и повреждение хранилища),
+// которые потребители (файловый writer, human-readable вывод в stderr,
+// кольцевой буфер для `taskm -audit`) могут обрабатывать независимо друг от
+// друга, не блокируя ни producer'а, ни остальных подписчиков.
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Kind - тип события, публикуемого на Bus.
+type Kind string
+
+const (
+	KindTaskAdded      Kind = "task_added"
+	KindTaskCompleted  Kind = "task_completed"
+	KindTaskDeleted    Kind = "task_deleted"
+	KindTaskEdited     Kind = "task_edited"
+	KindStoreFlushed   Kind = "store_flushed"
+	KindStoreCorrupted Kind = "store_corrupted"
+)
+
+// Event - одно событие, публикуемое в Bus. Payload - данные, специфичные
+// для Kind (например, task.Task для KindTaskAdded, ошибка для
+// KindStoreCorrupted); подписчики сами решают, как его отформатировать или
+// сериализовать.
+type Event struct {
+	Kind      Kind
+	Timestamp time.Time
+	Payload   interface{}
+}
+
+// NewEvent - удобный конструктор Event с проставленным текущим временем;
+// эквивалентен Event{Kind: kind, Timestamp: time.Now(), Payload: payload}.
+func NewEvent(kind Kind, payload interface{}) Event {
+	return Event{Kind: kind, Timestamp: time.Now(), Payload: payload}
+}
+
+// defaultSubscriberBuffer - размер канала подписчика по умолчанию (см.
+// Subscribe).
+const defaultSubscriberBuffer = 64
+
+// subscriber - внутреннее состояние одного подписчика: собственный
+// буферизированный канал и счетчик потерянных из-за переполнения событий.
+type subscriber struct {
+	ch      chan Event
+	dropped uint64
+}
+
+// Bus рассылает события любому числу подписчиков. В отличие от
+// logger.Logger.Log, где переполнение единственного канала молча роняет
+// сообщение для всех, у каждого подписчика здесь свой собственный
+// буферизированный канал - медленный подписчик теряет только свои события
+// (см. subscriber.dropped), не блокируя producer'а и остальных подписчиков.
+type Bus struct {
+	mu          sync.Mutex
+	subscribers map[int]*subscriber
+	nextID      int
+	closed      bool
+}
+
+// делаем что-то типа конструктора из ООП для Bus
+func NewBus() *Bus {
+	return &Bus{subscribers: make(map[int]*subscriber)}
+}
+
+// Subscribe регистрирует нового подписчика с буфером по умолчанию
+// (defaultSubscriberBuffer событий) и возвращает канал для чтения и функцию
+// cancel для отписки. После cancel канал закрывается и из него больше
+// ничего не придет.
+func (b *Bus) Subscribe() (<-chan Event, func()) {
+	return b.SubscribeWithBuffer(defaultSubscriberBuffer)
+}
+
+// SubscribeWithBuffer - то же самое, что и Subscribe, но с явно заданным
+// размером буфера канала подписчика.
+func (b *Bus) SubscribeWithBuffer(bufferSize int) (<-chan Event, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.nextID
+	b.nextID++
+	sub := &subscriber{ch: make(chan Event, bufferSize)}
+	b.subscribers[id] = sub
+
+	var cancelOnce sync.Once
+	cancel := func() {
+		cancelOnce.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			if _, ok := b.subscribers[id]; !ok {
+				return
+			}
+			delete(b.subscribers, id)
+			close(sub.ch)
+		})
+	}
+
+	return sub.ch, cancel
+}
+
+// Publish рассылает event всем текущим подписчикам. Подписчику с
+// переполненным буфером событие не доставляется - событие теряется только
+// для него (растет его subscriber.dropped), остальные подписчики и сам
+// Publish не блокируются. После Close - no-op.
+func (b *Bus) Publish(event Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	for _, sub := range b.subscribers {
+		select {
+		case sub.ch <- event:
+		default:
+			sub.dropped++
+		}
+	}
+}
+
+// Close отписывает и закрывает каналы всех текущих подписчиков. После Close
+// Publish становится no-op, а Subscribe по-прежнему регистрирует подписчика,
+// но тот не получит ни одного события, т.к. Publish больше ничего не рассылает.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for id, sub := range b.subscribers {
+		close(sub.ch)
+		delete(b.subscribers, id)
+	}
+}
+
+// Run запускает фоновую горутину, которая вызывает Close при отмене ctx;
+// wg позволяет вызывающему коду дождаться завершения (симметрично с
+// Archiver.Run и WritebackStore.Run).
+func (b *Bus) Run(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		<-ctx.Done()
+		b.Close()
+	}()
+}
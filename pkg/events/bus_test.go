@@ -0,0 +1,132 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBus_PublishDeliversToAllSubscribers(t *testing.T) {
+	bus := NewBus()
+	ch1, cancel1 := bus.Subscribe()
+	defer cancel1()
+	ch2, cancel2 := bus.Subscribe()
+	defer cancel2()
+
+	bus.Publish(NewEvent(KindTaskAdded, 42))
+
+	select {
+	case e := <-ch1:
+		if e.Kind != KindTaskAdded || e.Payload != 42 {
+			t.Errorf("unexpected event on subscriber 1: %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for event on subscriber 1")
+	}
+
+	select {
+	case e := <-ch2:
+		if e.Kind != KindTaskAdded || e.Payload != 42 {
+			t.Errorf("unexpected event on subscriber 2: %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for event on subscriber 2")
+	}
+}
+
+func TestBus_SlowSubscriberDropsOwnEventsOnly(t *testing.T) {
+	bus := NewBus()
+	slow, cancelSlow := bus.SubscribeWithBuffer(1)
+	defer cancelSlow()
+	fast, cancelFast := bus.SubscribeWithBuffer(10)
+	defer cancelFast()
+
+	for i := 0; i < 5; i++ {
+		bus.Publish(NewEvent(KindTaskAdded, i))
+	}
+
+	// Медленный подписчик не читает канал - буфер заполнен, события теряются
+	// только для него.
+	drained := 0
+	for {
+		select {
+		case <-slow:
+			drained++
+		default:
+			goto doneSlow
+		}
+	}
+doneSlow:
+	if drained != 1 {
+		t.Errorf("expected slow subscriber to have exactly 1 buffered event, got %d", drained)
+	}
+
+	drainedFast := 0
+	for {
+		select {
+		case <-fast:
+			drainedFast++
+		default:
+			goto doneFast
+		}
+	}
+doneFast:
+	if drainedFast != 5 {
+		t.Errorf("expected fast subscriber to receive all 5 events, got %d", drainedFast)
+	}
+}
+
+func TestBus_CancelClosesSubscriberChannel(t *testing.T) {
+	bus := NewBus()
+	ch, cancel := bus.Subscribe()
+	cancel()
+
+	if _, ok := <-ch; ok {
+		t.Error("expected channel to be closed after cancel")
+	}
+
+	// Повторный cancel не должен паниковать.
+	cancel()
+}
+
+func TestBus_PublishAfterCloseIsNoOp(t *testing.T) {
+	bus := NewBus()
+	ch, cancel := bus.Subscribe()
+	defer cancel()
+
+	bus.Close()
+	bus.Publish(NewEvent(KindTaskDeleted, 1))
+
+	if _, ok := <-ch; ok {
+		t.Error("expected subscriber channel to be closed after Bus.Close")
+	}
+}
+
+func TestBus_RunClosesOnContextCancel(t *testing.T) {
+	bus := NewBus()
+	ch, cancel := bus.Subscribe()
+	defer cancel()
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	bus.Run(ctx, &wg)
+
+	cancelCtx()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for Bus.Run to finish after context cancel")
+	}
+
+	if _, ok := <-ch; ok {
+		t.Error("expected subscriber channel to be closed after Bus.Run observes ctx.Done")
+	}
+}
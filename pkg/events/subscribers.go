@@ -0,0 +1,193 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+const eventTimeFormat = "2006-01-02 15:04:05"
+
+// jsonEventLine - форма события на диске: по одной JSON-строке на событие.
+type jsonEventLine struct {
+	Timestamp string      `json:"timestamp"`
+	Kind      Kind        `json:"kind"`
+	Payload   interface{} `json:"payload"`
+}
+
+// FileSubscriber пишет каждое событие Bus отдельной JSON-строкой в файл -
+// аналог прежнего файлового вывода logger.Logger, но для типизированных
+// событий вместо произвольных строк.
+type FileSubscriber struct {
+	path string
+	file *os.File
+}
+
+// NewFileSubscriber открывает (или создает) файл path для дозаписи событий.
+func NewFileSubscriber(path string) (*FileSubscriber, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть файл событий %s: %w", path, err)
+	}
+	return &FileSubscriber{path: path, file: file}, nil
+}
+
+// Run подписывается на bus и пишет каждое полученное событие в файл до
+// закрытия канала подписки или отмены ctx (после отмены подписчик успевает
+// дописать то, что уже было в его буфере, прежде чем завершиться).
+func (f *FileSubscriber) Run(ctx context.Context, bus *Bus, wg *sync.WaitGroup) {
+	ch, cancel := bus.Subscribe()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer f.file.Close()
+	EventLoop:
+		for {
+			select {
+			case e, ok := <-ch:
+				if !ok {
+					break EventLoop
+				}
+				f.write(e)
+			case <-ctx.Done():
+				cancel()
+				for e := range ch {
+					f.write(e)
+				}
+				break EventLoop
+			}
+		}
+	}()
+}
+
+func (f *FileSubscriber) write(e Event) {
+	line := jsonEventLine{
+		Timestamp: e.Timestamp.Format(eventTimeFormat),
+		Kind:      e.Kind,
+		Payload:   e.Payload,
+	}
+	data, err := json.Marshal(line)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "events: не удалось сериализовать событие %s: %v\n", e.Kind, err)
+		return
+	}
+	if _, err := f.file.Write(append(data, '\n')); err != nil {
+		fmt.Fprintf(os.Stderr, "events: ошибка записи события в файл %s: %v\n", f.path, err)
+	}
+}
+
+// StderrSubscriber выводит события в человекочитаемом виде в stderr -
+// удобно для разработки и отладки, когда неохота парсить JSON-лог.
+type StderrSubscriber struct{}
+
+// NewStderrSubscriber создает подписчика, выводящего события в stderr.
+func NewStderrSubscriber() *StderrSubscriber {
+	return &StderrSubscriber{}
+}
+
+// Run подписывается на bus и печатает каждое событие в stderr до закрытия
+// канала подписки или отмены ctx.
+func (s *StderrSubscriber) Run(ctx context.Context, bus *Bus, wg *sync.WaitGroup) {
+	ch, cancel := bus.Subscribe()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+	EventLoop:
+		for {
+			select {
+			case e, ok := <-ch:
+				if !ok {
+					break EventLoop
+				}
+				s.print(e)
+			case <-ctx.Done():
+				cancel()
+				for e := range ch {
+					s.print(e)
+				}
+				break EventLoop
+			}
+		}
+	}()
+}
+
+func (s *StderrSubscriber) print(e Event) {
+	fmt.Fprintf(os.Stderr, "[%s] %s: %v\n", e.Timestamp.Format(eventTimeFormat), e.Kind, e.Payload)
+}
+
+// RingBufferSubscriber хранит в памяти последние size событий Bus - CLI
+// может вызвать Dump, чтобы получить их для команды `taskm -audit N`,
+// не читая файл событий с диска.
+type RingBufferSubscriber struct {
+	mu     sync.Mutex
+	buf    []Event
+	size   int
+	start  int // индекс самого старого события в buf
+	filled bool
+}
+
+// NewRingBufferSubscriber создает кольцевой буфер на size последних событий.
+func NewRingBufferSubscriber(size int) *RingBufferSubscriber {
+	if size <= 0 {
+		size = 1
+	}
+	return &RingBufferSubscriber{buf: make([]Event, size), size: size}
+}
+
+// Run подписывается на bus и складывает каждое полученное событие в
+// кольцевой буфер до закрытия канала подписки или отмены ctx.
+func (r *RingBufferSubscriber) Run(ctx context.Context, bus *Bus, wg *sync.WaitGroup) {
+	ch, cancel := bus.Subscribe()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+	EventLoop:
+		for {
+			select {
+			case e, ok := <-ch:
+				if !ok {
+					break EventLoop
+				}
+				r.push(e)
+			case <-ctx.Done():
+				cancel()
+				for e := range ch {
+					r.push(e)
+				}
+				break EventLoop
+			}
+		}
+	}()
+}
+
+func (r *RingBufferSubscriber) push(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.buf[r.start] = e
+	r.start = (r.start + 1) % r.size
+	if r.start == 0 {
+		r.filled = true
+	}
+}
+
+// Dump возвращает до n последних событий (от самого старого к самому
+// новому). n <= 0 означает "вернуть все, что есть в буфере".
+func (r *RingBufferSubscriber) Dump(n int) []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var ordered []Event
+	if r.filled {
+		ordered = append(ordered, r.buf[r.start:]...)
+		ordered = append(ordered, r.buf[:r.start]...)
+	} else {
+		ordered = append(ordered, r.buf[:r.start]...)
+	}
+
+	if n <= 0 || n >= len(ordered) {
+		return ordered
+	}
+	return ordered[len(ordered)-n:]
+}
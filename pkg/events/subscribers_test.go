@@ -0,0 +1,118 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFileSubscriber_WritesJSONLines(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "events.log")
+
+	sub, err := NewFileSubscriber(path)
+	if err != nil {
+		t.Fatalf("NewFileSubscriber failed: %v", err)
+	}
+
+	bus := NewBus()
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	sub.Run(ctx, bus, &wg)
+
+	bus.Publish(NewEvent(KindTaskAdded, "Buy milk"))
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for FileSubscriber to finish")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 line, got %d: %q", len(lines), string(data))
+	}
+
+	var decoded jsonEventLine
+	if err := json.Unmarshal([]byte(lines[0]), &decoded); err != nil {
+		t.Fatalf("failed to unmarshal event line: %v", err)
+	}
+	if decoded.Kind != KindTaskAdded || decoded.Payload != "Buy milk" {
+		t.Errorf("unexpected decoded event: %+v", decoded)
+	}
+}
+
+func TestRingBufferSubscriber_KeepsOnlyLastN(t *testing.T) {
+	sub := NewRingBufferSubscriber(3)
+	bus := NewBus()
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	sub.Run(ctx, bus, &wg)
+
+	for i := 0; i < 5; i++ {
+		bus.Publish(NewEvent(KindTaskAdded, i))
+	}
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for RingBufferSubscriber to finish")
+	}
+
+	dumped := sub.Dump(0)
+	if len(dumped) != 3 {
+		t.Fatalf("expected 3 buffered events, got %d", len(dumped))
+	}
+	want := []int{2, 3, 4}
+	for i, e := range dumped {
+		if e.Payload != want[i] {
+			t.Errorf("event %d: expected payload %d, got %v", i, want[i], e.Payload)
+		}
+	}
+}
+
+func TestRingBufferSubscriber_DumpWithLimit(t *testing.T) {
+	sub := NewRingBufferSubscriber(10)
+	bus := NewBus()
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	sub.Run(ctx, bus, &wg)
+
+	for i := 0; i < 4; i++ {
+		bus.Publish(NewEvent(KindTaskAdded, i))
+	}
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	wg.Wait()
+
+	dumped := sub.Dump(2)
+	if len(dumped) != 2 {
+		t.Fatalf("expected 2 events with tail limit, got %d", len(dumped))
+	}
+	if dumped[0].Payload != 2 || dumped[1].Payload != 3 {
+		t.Errorf("expected last 2 events [2,3], got %+v", dumped)
+	}
+}
@@ -0,0 +1,194 @@
+// Package inspector позволяет заглянуть в архив завершенных задач и
+// восстановить, удалить или просмотреть их без ручного редактирования
+// archive.txt.
+package inspector
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"taskm/pkg/result"
+	"taskm/pkg/storage"
+	"taskm/pkg/task"
+	"taskm/pkg/utils"
+)
+
+// Filter задает условия отбора задач из архива для ListArchived.
+// Нулевое значение Filter означает "без фильтрации".
+type Filter struct {
+	Priority string // "" = любой приоритет
+}
+
+func (f Filter) matches(t task.Task) bool {
+	if f.Priority != "" && t.Priority != f.Priority {
+		return false
+	}
+	return true
+}
+
+// Inspector - инструмент для чтения и изменения архива завершенных задач.
+type Inspector struct {
+	archivePath    string
+	tasks          *[]task.Task
+	taskMutex      *sync.Mutex
+	idGen          *utils.IDGenerator
+	fileMutex      *sync.Mutex
+	results        *result.Registry // реестр sidecar-файлов активных задач (может быть nil)
+	archiveResults *result.Registry // реестр sidecar-файлов в архиве (может быть nil)
+}
+
+// делаем что-то типа конструктора из ООП для инспектора
+func NewInspector(archivePath string, tasks *[]task.Task, taskMutex *sync.Mutex, archiveMutex *sync.Mutex, idGen *utils.IDGenerator) *Inspector {
+	return NewInspectorWithOptions(archivePath, tasks, taskMutex, archiveMutex, idGen, nil, nil)
+}
+
+// NewInspectorWithOptions - то же самое, что и NewInspector, но дополнительно
+// принимает реестры sidecar-файлов результатов (см. pkg/result) для активных
+// задач и для архива, чтобы восстановление/удаление/очистка архива
+// затрагивали и заметки задач. Любой из них может быть nil, тогда
+// sidecar-файлы не трогаются.
+//
+// archiveMutex должен быть тем же *sync.Mutex, что передается
+// archiver.NewArchiverWithOptions для того же archivePath: Inspector
+// читает-изменяет-перезаписывает этот файл по командам CLI, конкурируя с
+// периодической дозаписью Archiver'а, и без общего мьютекса это гонка на
+// одном и том же файле.
+func NewInspectorWithOptions(archivePath string, tasks *[]task.Task, taskMutex *sync.Mutex, archiveMutex *sync.Mutex, idGen *utils.IDGenerator, results *result.Registry, archiveResults *result.Registry) *Inspector {
+	return &Inspector{
+		archivePath:    archivePath,
+		tasks:          tasks,
+		taskMutex:      taskMutex,
+		idGen:          idGen,
+		fileMutex:      archiveMutex,
+		results:        results,
+		archiveResults: archiveResults,
+	}
+}
+
+// ListArchived возвращает заархивированные задачи, соответствующие filter.
+func (ins *Inspector) ListArchived(filter Filter) ([]task.Task, error) {
+	ins.fileMutex.Lock()
+	defer ins.fileMutex.Unlock()
+
+	archived, _, err := storage.LoadTasks(ins.archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать архив %s: %w", ins.archivePath, err)
+	}
+
+	filtered := make([]task.Task, 0, len(archived))
+	for _, t := range archived {
+		if filter.matches(t) {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered, nil
+}
+
+// RestoreArchivedTask переносит задачу с указанным ID из архива обратно в
+// список активных задач.
+func (ins *Inspector) RestoreArchivedTask(id int) error {
+	ins.fileMutex.Lock()
+	defer ins.fileMutex.Unlock()
+
+	archived, _, err := storage.LoadTasks(ins.archivePath)
+	if err != nil {
+		return fmt.Errorf("не удалось прочитать архив %s: %w", ins.archivePath, err)
+	}
+
+	foundIndex := -1
+	for i, t := range archived {
+		if t.ID == id {
+			foundIndex = i
+			break
+		}
+	}
+	if foundIndex == -1 {
+		return fmt.Errorf("задача с ID %d не найдена в архиве", id)
+	}
+
+	restored := archived[foundIndex]
+	remaining := append(archived[:foundIndex:foundIndex], archived[foundIndex+1:]...)
+
+	if err := storage.SaveTasks(ins.archivePath, remaining); err != nil {
+		return fmt.Errorf("не удалось перезаписать архив %s при восстановлении задачи %d: %w", ins.archivePath, id, err)
+	}
+
+	ins.taskMutex.Lock()
+	*ins.tasks = append(*ins.tasks, restored)
+	ins.taskMutex.Unlock()
+
+	ins.idGen.UpdateGenerator(restored.ID)
+
+	if ins.archiveResults != nil && ins.results != nil {
+		if err := ins.archiveResults.CopyTo(id, ins.results); err != nil {
+			return fmt.Errorf("не удалось вернуть sidecar-файл задачи %d из архива: %w", id, err)
+		}
+		if err := ins.archiveResults.Delete(id); err != nil {
+			return fmt.Errorf("не удалось удалить sidecar-файл задачи %d из архива после восстановления: %w", id, err)
+		}
+	}
+
+	return nil
+}
+
+// DeleteArchivedTask безвозвратно удаляет задачу с указанным ID из архива.
+func (ins *Inspector) DeleteArchivedTask(id int) error {
+	ins.fileMutex.Lock()
+	defer ins.fileMutex.Unlock()
+
+	archived, _, err := storage.LoadTasks(ins.archivePath)
+	if err != nil {
+		return fmt.Errorf("не удалось прочитать архив %s: %w", ins.archivePath, err)
+	}
+
+	foundIndex := -1
+	for i, t := range archived {
+		if t.ID == id {
+			foundIndex = i
+			break
+		}
+	}
+	if foundIndex == -1 {
+		return fmt.Errorf("задача с ID %d не найдена в архиве", id)
+	}
+
+	remaining := append(archived[:foundIndex:foundIndex], archived[foundIndex+1:]...)
+
+	if err := storage.SaveTasks(ins.archivePath, remaining); err != nil {
+		return fmt.Errorf("не удалось перезаписать архив %s при удалении задачи %d: %w", ins.archivePath, id, err)
+	}
+
+	if ins.archiveResults != nil {
+		if err := ins.archiveResults.Delete(id); err != nil {
+			return fmt.Errorf("не удалось удалить sidecar-файл задачи %d: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// DeleteAllArchivedTasks очищает архив целиком и возвращает число удаленных задач.
+func (ins *Inspector) DeleteAllArchivedTasks() (int, error) {
+	ins.fileMutex.Lock()
+	defer ins.fileMutex.Unlock()
+
+	archived, _, err := storage.LoadTasks(ins.archivePath)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось прочитать архив %s: %w", ins.archivePath, err)
+	}
+	if len(archived) == 0 {
+		return 0, nil
+	}
+
+	if err := storage.SaveTasks(ins.archivePath, []task.Task{}); err != nil {
+		return 0, fmt.Errorf("не удалось очистить архив %s: %w", ins.archivePath, err)
+	}
+
+	if ins.archiveResults != nil {
+		for _, t := range archived {
+			if err := ins.archiveResults.Delete(t.ID); err != nil {
+				fmt.Fprintf(os.Stderr, "Инспектор: не удалось удалить sidecar-файл задачи %d: %v\n", t.ID, err)
+			}
+		}
+	}
+	return len(archived), nil
+}
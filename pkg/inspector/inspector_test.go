@@ -0,0 +1,224 @@
+package inspector
+
+import (
+	"path/filepath"
+	"sync"
+	"taskm/pkg/result"
+	"taskm/pkg/storage"
+	"taskm/pkg/task"
+	"taskm/pkg/utils"
+	"testing"
+)
+
+func seedArchive(t *testing.T, path string, tasks []task.Task) {
+	t.Helper()
+	if err := storage.SaveTasks(path, tasks); err != nil {
+		t.Fatalf("failed to seed archive %s: %v", path, err)
+	}
+}
+
+func TestListArchived_Filter(t *testing.T) {
+	dir := t.TempDir()
+	archivePath := filepath.Join(dir, "archive.txt")
+	seedArchive(t, archivePath, []task.Task{
+		task.AddTask(1, "Task 1", task.PriorityHigh),
+		task.AddTask(2, "Task 2", task.PriorityLow),
+	})
+
+	var tasks []task.Task
+	var taskMutex sync.Mutex
+	var archiveMutex sync.Mutex
+	idGen := utils.NewIDGenerator(2)
+
+	ins := NewInspector(archivePath, &tasks, &taskMutex, &archiveMutex, idGen)
+
+	all, err := ins.ListArchived(Filter{})
+	if err != nil {
+		t.Fatalf("ListArchived failed: %v", err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("expected 2 archived tasks, got %d", len(all))
+	}
+
+	highOnly, err := ins.ListArchived(Filter{Priority: task.PriorityHigh})
+	if err != nil {
+		t.Fatalf("ListArchived with filter failed: %v", err)
+	}
+	if len(highOnly) != 1 || highOnly[0].ID != 1 {
+		t.Errorf("expected only task 1, got %+v", highOnly)
+	}
+}
+
+func TestRestoreArchivedTask(t *testing.T) {
+	dir := t.TempDir()
+	archivePath := filepath.Join(dir, "archive.txt")
+	seedArchive(t, archivePath, []task.Task{
+		task.AddTask(1, "Task 1", task.PriorityHigh),
+		task.AddTask(2, "Task 2", task.PriorityLow),
+	})
+
+	tasks := []task.Task{}
+	var taskMutex sync.Mutex
+	var archiveMutex sync.Mutex
+	idGen := utils.NewIDGenerator(2)
+
+	ins := NewInspector(archivePath, &tasks, &taskMutex, &archiveMutex, idGen)
+
+	if err := ins.RestoreArchivedTask(1); err != nil {
+		t.Fatalf("RestoreArchivedTask failed: %v", err)
+	}
+
+	if len(tasks) != 1 || tasks[0].ID != 1 {
+		t.Errorf("expected restored task 1 in live tasks, got %+v", tasks)
+	}
+
+	remaining, _, err := storage.LoadTasks(archivePath)
+	if err != nil {
+		t.Fatalf("LoadTasks failed: %v", err)
+	}
+	if len(remaining) != 1 || remaining[0].ID != 2 {
+		t.Errorf("expected only task 2 left in archive, got %+v", remaining)
+	}
+}
+
+func TestRestoreArchivedTask_NotFound(t *testing.T) {
+	dir := t.TempDir()
+	archivePath := filepath.Join(dir, "archive.txt")
+	seedArchive(t, archivePath, []task.Task{})
+
+	tasks := []task.Task{}
+	var taskMutex sync.Mutex
+	var archiveMutex sync.Mutex
+	idGen := utils.NewIDGenerator(0)
+
+	ins := NewInspector(archivePath, &tasks, &taskMutex, &archiveMutex, idGen)
+
+	if err := ins.RestoreArchivedTask(99); err == nil {
+		t.Error("expected error restoring non-existent task")
+	}
+}
+
+func TestDeleteArchivedTask(t *testing.T) {
+	dir := t.TempDir()
+	archivePath := filepath.Join(dir, "archive.txt")
+	seedArchive(t, archivePath, []task.Task{
+		task.AddTask(1, "Task 1", task.PriorityHigh),
+		task.AddTask(2, "Task 2", task.PriorityLow),
+	})
+
+	tasks := []task.Task{}
+	var taskMutex sync.Mutex
+	var archiveMutex sync.Mutex
+	idGen := utils.NewIDGenerator(2)
+	ins := NewInspector(archivePath, &tasks, &taskMutex, &archiveMutex, idGen)
+
+	if err := ins.DeleteArchivedTask(1); err != nil {
+		t.Fatalf("DeleteArchivedTask failed: %v", err)
+	}
+
+	remaining, _, err := storage.LoadTasks(archivePath)
+	if err != nil {
+		t.Fatalf("LoadTasks failed: %v", err)
+	}
+	if len(remaining) != 1 || remaining[0].ID != 2 {
+		t.Errorf("expected only task 2 left in archive, got %+v", remaining)
+	}
+}
+
+func TestDeleteAllArchivedTasks(t *testing.T) {
+	dir := t.TempDir()
+	archivePath := filepath.Join(dir, "archive.txt")
+	seedArchive(t, archivePath, []task.Task{
+		task.AddTask(1, "Task 1", task.PriorityHigh),
+		task.AddTask(2, "Task 2", task.PriorityLow),
+	})
+
+	tasks := []task.Task{}
+	var taskMutex sync.Mutex
+	var archiveMutex sync.Mutex
+	idGen := utils.NewIDGenerator(2)
+	ins := NewInspector(archivePath, &tasks, &taskMutex, &archiveMutex, idGen)
+
+	count, err := ins.DeleteAllArchivedTasks()
+	if err != nil {
+		t.Fatalf("DeleteAllArchivedTasks failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("expected 2 deleted tasks, got %d", count)
+	}
+
+	remaining, _, err := storage.LoadTasks(archivePath)
+	if err != nil {
+		t.Fatalf("LoadTasks failed: %v", err)
+	}
+	if len(remaining) != 0 {
+		t.Errorf("expected archive to be empty, got %+v", remaining)
+	}
+}
+
+func TestDeleteArchivedTask_RemovesSidecar(t *testing.T) {
+	dir := t.TempDir()
+	archivePath := filepath.Join(dir, "archive.txt")
+	seedArchive(t, archivePath, []task.Task{
+		task.AddTask(1, "Task 1", task.PriorityHigh),
+	})
+
+	archiveResults := result.NewRegistry(filepath.Join(dir, "archive-results"))
+	_, _ = archiveResults.Writer(1).Write([]byte("note"))
+
+	tasks := []task.Task{}
+	var taskMutex sync.Mutex
+	var archiveMutex sync.Mutex
+	idGen := utils.NewIDGenerator(1)
+	ins := NewInspectorWithOptions(archivePath, &tasks, &taskMutex, &archiveMutex, idGen, nil, archiveResults)
+
+	if err := ins.DeleteArchivedTask(1); err != nil {
+		t.Fatalf("DeleteArchivedTask failed: %v", err)
+	}
+
+	data, err := archiveResults.Read(1)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if data != nil {
+		t.Errorf("expected sidecar to be removed, got %q", data)
+	}
+}
+
+func TestRestoreArchivedTask_MovesSidecar(t *testing.T) {
+	dir := t.TempDir()
+	archivePath := filepath.Join(dir, "archive.txt")
+	seedArchive(t, archivePath, []task.Task{
+		task.AddTask(1, "Task 1", task.PriorityHigh),
+	})
+
+	liveResults := result.NewRegistry(filepath.Join(dir, "results"))
+	archiveResults := result.NewRegistry(filepath.Join(dir, "archive-results"))
+	_, _ = archiveResults.Writer(1).Write([]byte("archived note"))
+
+	tasks := []task.Task{}
+	var taskMutex sync.Mutex
+	var archiveMutex sync.Mutex
+	idGen := utils.NewIDGenerator(1)
+	ins := NewInspectorWithOptions(archivePath, &tasks, &taskMutex, &archiveMutex, idGen, liveResults, archiveResults)
+
+	if err := ins.RestoreArchivedTask(1); err != nil {
+		t.Fatalf("RestoreArchivedTask failed: %v", err)
+	}
+
+	restoredData, err := liveResults.Read(1)
+	if err != nil {
+		t.Fatalf("Read from live results failed: %v", err)
+	}
+	if string(restoredData) != "archived note" {
+		t.Errorf("expected restored sidecar content %q, got %q", "archived note", string(restoredData))
+	}
+
+	archivedData, err := archiveResults.Read(1)
+	if err != nil {
+		t.Fatalf("Read from archive results failed: %v", err)
+	}
+	if archivedData != nil {
+		t.Errorf("expected archive sidecar to be removed after restore, got %q", archivedData)
+	}
+}
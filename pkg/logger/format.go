@@ -0,0 +1,95 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Level - уровень важности записи лога: Debug ниже Info ниже Warn ниже Error.
+// Нулевое значение - LevelDebug, так что существующий код, не указывающий
+// MinLevel явно, продолжает видеть все сообщения.
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// String возвращает имя уровня в нижнем регистре - используется
+// JSONFormatter и для отладочного вывода.
+func (lv Level) String() string {
+	switch lv {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	default:
+		return fmt.Sprintf("level(%d)", int(lv))
+	}
+}
+
+// Record - одна запись лога, передаваемая в Formatter.Format.
+type Record struct {
+	Time   time.Time
+	Level  Level
+	Msg    string
+	Fields map[string]interface{}
+}
+
+// Formatter превращает Record в одну строку лога без завершающего перевода
+// строки - его дописывает writeLogEntry.
+type Formatter interface {
+	Format(r Record) string
+}
+
+// TextFormatter - форматтер по умолчанию, сохраняющий исторический формат
+// "[timestamp] message" и не учитывающий Level/Fields.
+type TextFormatter struct{}
+
+// делаем что-то типа конструктора из ООП для TextFormatter
+func NewTextFormatter() *TextFormatter {
+	return &TextFormatter{}
+}
+
+func (f *TextFormatter) Format(r Record) string {
+	return fmt.Sprintf("[%s] %s", r.Time.Format(logTimeFormat), r.Msg)
+}
+
+// jsonRecord - представление Record на проводе для JSONFormatter.
+type jsonRecord struct {
+	TS     string                 `json:"ts"`
+	Level  string                 `json:"level"`
+	Msg    string                 `json:"msg"`
+	Fields map[string]interface{} `json:"fields,omitempty"`
+}
+
+// JSONFormatter форматирует запись как JSON-объект вида
+// {"ts":"...","level":"...","msg":"...","fields":{...}}.
+type JSONFormatter struct{}
+
+// NewJSONFormatter - то же самое, что и NewTextFormatter, но отдает
+// JSON-форматтер.
+func NewJSONFormatter() *JSONFormatter {
+	return &JSONFormatter{}
+}
+
+func (f *JSONFormatter) Format(r Record) string {
+	data, err := json.Marshal(jsonRecord{
+		TS:     r.Time.Format(logTimeFormat),
+		Level:  r.Level.String(),
+		Msg:    r.Msg,
+		Fields: r.Fields,
+	})
+	if err != nil {
+		return fmt.Sprintf(`{"ts":"%s","level":"error","msg":"logger: failed to marshal record: %s"}`,
+			r.Time.Format(logTimeFormat), err)
+	}
+	return string(data)
+}
@@ -0,0 +1,125 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestJSONFormatter_RoundTrips(t *testing.T) {
+	f := NewJSONFormatter()
+	line := f.Format(Record{
+		Time:   time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC),
+		Level:  LevelWarn,
+		Msg:    "disk almost full",
+		Fields: map[string]interface{}{"free_mb": float64(42)},
+	})
+
+	var decoded struct {
+		TS     string                 `json:"ts"`
+		Level  string                 `json:"level"`
+		Msg    string                 `json:"msg"`
+		Fields map[string]interface{} `json:"fields"`
+	}
+	if err := json.Unmarshal([]byte(line), &decoded); err != nil {
+		t.Fatalf("failed to unmarshal JSON log line %q: %v", line, err)
+	}
+	if decoded.Level != "warn" {
+		t.Errorf("expected level %q, got %q", "warn", decoded.Level)
+	}
+	if decoded.Msg != "disk almost full" {
+		t.Errorf("expected msg %q, got %q", "disk almost full", decoded.Msg)
+	}
+	if decoded.Fields["free_mb"] != float64(42) {
+		t.Errorf("expected fields.free_mb 42, got %v", decoded.Fields["free_mb"])
+	}
+}
+
+func TestTextFormatter_PreservesHistoricalFormat(t *testing.T) {
+	f := NewTextFormatter()
+	ts := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	line := f.Format(Record{Time: ts, Level: LevelError, Msg: "boom"})
+	want := "[" + ts.Format(logTimeFormat) + "] boom"
+	if line != want {
+		t.Errorf("expected %q, got %q", want, line)
+	}
+}
+
+func TestLogger_MinLevelDropsBelowThresholdBeforeChannel(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "minlevel_test.log")
+	var wg sync.WaitGroup
+
+	lg, err := NewLoggerWithOptions(logPath, 10, &wg, LoggerOptions{MinLevel: LevelWarn})
+	if err != nil {
+		t.Fatalf("NewLoggerWithOptions failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	lg.Run(ctx)
+
+	lg.Log("should be filtered, this is Info")
+	lg.Logf(LevelWarn, "should survive: %s", "warn level")
+	time.Sleep(50 * time.Millisecond)
+
+	if lg.WrittenCount() != 1 {
+		t.Errorf("expected WrittenCount 1 (only the Warn message), got %d", lg.WrittenCount())
+	}
+	if lg.DroppedCount() != 0 {
+		t.Errorf("expected DroppedCount 0 (filtered messages are not drops), got %d", lg.DroppedCount())
+	}
+
+	cancel()
+	wg.Wait()
+
+	logContent := readLogFile(t, logPath)
+	if strings.Contains(logContent, "should be filtered") {
+		t.Error("expected Info message below MinLevel=Warn to be filtered out")
+	}
+	if !strings.Contains(logContent, "should survive") {
+		t.Error("expected Warn message to pass the MinLevel filter")
+	}
+}
+
+func TestLogger_LogFieldsIncludesFieldsInJSONOutput(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "fields_test.log")
+	var wg sync.WaitGroup
+
+	lg, err := NewLoggerWithOptions(logPath, 10, &wg, LoggerOptions{Formatter: NewJSONFormatter()})
+	if err != nil {
+		t.Fatalf("NewLoggerWithOptions failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	lg.Run(ctx)
+
+	lg.LogFields(LevelError, "task failed", map[string]interface{}{"task_id": float64(7)})
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	wg.Wait()
+
+	logContent := readLogFile(t, logPath)
+	lines := strings.Split(strings.TrimSpace(logContent), "\n")
+	last := lines[len(lines)-1]
+
+	var decoded struct {
+		Level  string                 `json:"level"`
+		Msg    string                 `json:"msg"`
+		Fields map[string]interface{} `json:"fields"`
+	}
+	if err := json.Unmarshal([]byte(last), &decoded); err != nil {
+		t.Fatalf("failed to unmarshal JSON log line %q: %v", last, err)
+	}
+	if decoded.Level != "error" || decoded.Msg != "task failed" {
+		t.Errorf("unexpected decoded record: %+v", decoded)
+	}
+	if decoded.Fields["task_id"] != float64(7) {
+		t.Errorf("expected fields.task_id 7, got %v", decoded.Fields["task_id"])
+	}
+}
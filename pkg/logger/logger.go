@@ -5,33 +5,96 @@ import (
 	"fmt"
 	"os"
 	"sync"
+	"sync/atomic"
+	"taskm/pkg/logrotate"
 	"time"
 )
 
 const logTimeFormat = "2006-01-02 15:04:05"
 
+// LogMode управляет поведением Log при заполненном буфере канала - аналог
+// Docker-овского LogMode.
+type LogMode int
+
+const (
+	// LogModeNonBlock - поведение по умолчанию (нулевое значение): при
+	// заполненном буфере сообщение роняется, а счетчик droppedCount растет.
+	LogModeNonBlock LogMode = iota
+	// LogModeBlocking - Log блокирует вызывающего до тех пор, пока в буфере
+	// не появится место; сообщения никогда не теряются.
+	LogModeBlocking
+)
+
+// LoggerOptions - конфигурация логгера для NewLoggerWithOptions.
+type LoggerOptions struct {
+	Mode      LogMode
+	Rotate    logrotate.RotateConfig
+	Formatter Formatter // nil = TextFormatter (исторический формат "[ts] message")
+	MinLevel  Level     // сообщения ниже этого уровня отбрасываются до попадания в канал
+}
+
 type Logger struct {
-	logChan  chan string
+	logChan  chan Record
 	filePath string
 	file     *os.File
 	wg       *sync.WaitGroup
 	mu sync.Mutex
 	closed bool
+	rotator *logrotate.Rotator
+	mode    LogMode
+
+	formatter Formatter
+	minLevel  Level
+
+	droppedCount    uint64 // атомарный счетчик; инкрементируется из Log в режиме LogModeNonBlock
+	writtenCount    uint64 // атомарный счетчик; инкрементируется из Log при успешной отправке в канал
+	reportedDropped uint64 // сколько из droppedCount уже отражено строкой "[logger] dropped N messages"; трогается только из горутины Run
 
+	subMu       sync.Mutex
+	subscribers map[int]*logSubscriber // живые подписчики на поток лог-сообщений, см. watch.go
+	nextSubID   int
+
+	runStarted bool          // выставляется в Run; Shutdown ждет stoppedCh только если горутина-писатель вообще запускалась
+	stopCh     chan struct{} // закрывается в Shutdown, чтобы попросить горутину Run отдать канал и выйти, не закрывая файл
+	stoppedCh  chan struct{} // закрывается горутиной Run в ответ на stopCh, см. shutdown.go
 }
 
 // делаем что-то типа конструктора из ООП для логгера
 func NewLogger(filePath string, bufferSize int, wg *sync.WaitGroup) (*Logger, error) {
+	return NewLoggerWithOptions(filePath, bufferSize, wg, LoggerOptions{})
+}
+
+// NewLoggerWithRotation - то же самое, что и NewLogger, но дополнительно
+// принимает политику ротации файла лога (см. pkg/logrotate).
+func NewLoggerWithRotation(filePath string, bufferSize int, wg *sync.WaitGroup, rotate logrotate.RotateConfig) (*Logger, error) {
+	return NewLoggerWithOptions(filePath, bufferSize, wg, LoggerOptions{Rotate: rotate})
+}
+
+// NewLoggerWithOptions - то же самое, что и NewLogger, но принимает полный
+// набор опций (режим доставки логов, политика ротации) через LoggerOptions.
+func NewLoggerWithOptions(filePath string, bufferSize int, wg *sync.WaitGroup, opts LoggerOptions) (*Logger, error) {
 	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
 		return nil, fmt.Errorf("Не удалось открыть файл для логирования %s: %w", filePath, err)
 	}
 
+	formatter := opts.Formatter
+	if formatter == nil {
+		formatter = NewTextFormatter()
+	}
+
 	l := &Logger{
-		logChan:  make(chan string, bufferSize),
-		filePath: filePath,
-		file:     file,
-		wg:       wg,
+		logChan:     make(chan Record, bufferSize),
+		filePath:    filePath,
+		file:        file,
+		wg:          wg,
+		rotator:     logrotate.NewRotator(filePath, opts.Rotate),
+		mode:        opts.Mode,
+		formatter:   formatter,
+		minLevel:    opts.MinLevel,
+		subscribers: make(map[int]*logSubscriber),
+		stopCh:      make(chan struct{}),
+		stoppedCh:   make(chan struct{}),
 	}
 
 	return l, nil
@@ -39,55 +102,187 @@ func NewLogger(filePath string, bufferSize int, wg *sync.WaitGroup) (*Logger, er
 
 // Запускаем горутину, которая будет слушать канал и записывать в файл
 func (l *Logger) Run(ctx context.Context){
+	l.mu.Lock()
+	l.runStarted = true
+	l.mu.Unlock()
+
 	l.wg.Add(1)
 	fmt.Println("Logger started")
 	go func() {
 		defer l.wg.Done()
-		defer l.file.Close()
 		fmt.Println("Logger goroutine is runnning")
 	LogLoop:
 		for{
 			select{
-			case msg, ok := <- l.logChan:
-				if !ok {
-					fmt.Println("Log channel closed")
-					break LogLoop	
-				}
-				timestamp := time.Now().Format(logTimeFormat)
-				logEntry := fmt.Sprintf("[%s] %s\n", timestamp, msg)
-				if _, err := l.file.WriteString(logEntry); err != nil {
-					fmt.Printf("Ошибка записи в лог файл %s: %v\n", l.filePath, err)
-				}
-			
+			case rec := <- l.logChan:
+				// logChan больше нигде не закрывается (см. ctx.Done и
+				// l.stopCh ниже - оба останавливают цикл флагом l.closed,
+				// а не закрытием канала), поэтому читать тут можно без
+				// проверки "канал закрыт".
+				l.writeLogEntry(rec)
+				l.maybeReportDrops()
+
 			case <- ctx.Done():
 				fmt.Println("Logger context done")
-				close(l.logChan)
-				for msg := range l.logChan {
-					timestamp := time.Now().Format(logTimeFormat)
-					logEntry := fmt.Sprintf("[%s] %s\n", timestamp, msg)
-					if _, err := l.file.WriteString(logEntry); err != nil {
-						fmt.Printf("Ошибка записи в лог файл %s: %v\n", l.filePath, err)
+				// Помечаем логгер закрытым ДО дренажа и не закрываем сам
+				// logChan: закрытие канала тут гонялось бы с Log/enqueue из
+				// других горутин и могло бы запаниковать на "send on closed
+				// channel". l.closed под мьютексом - единственный сигнал,
+				// которого enqueue() достаточно, чтобы отказаться от отправки.
+				l.mu.Lock()
+				l.closed = true
+				l.mu.Unlock()
+			drainCtxDone:
+				for {
+					select {
+					case rec := <-l.logChan:
+						l.writeLogEntry(rec)
+					default:
+						break drainCtxDone
 					}
 				}
+				l.maybeReportDrops()
+				l.file.Close()
 				fmt.Println("Logger goroutine is done")
 				break LogLoop
+
+			case <-l.stopCh:
+				// Graceful Shutdown запросил остановку: отдаем канал и файл
+				// Shutdown'у, который сам дочитает оставшиеся сообщения с
+				// учетом дедлайна и сам закроет файл - поэтому тут файл не
+				// закрываем.
+				close(l.stoppedCh)
+				break LogLoop
 			}
 		}
 		fmt.Println("Logger goroutine stopped")
 	}()
 }
 
+// Log пишет сообщение на уровне LevelInfo - оставлено для обратной
+// совместимости с кодом, написанным до появления уровней важности.
 func (l *Logger) Log(message string) {
+	l.enqueue(Record{Level: LevelInfo, Msg: message})
+}
+
+// Logf работает как Log, но форматирует сообщение через fmt.Sprintf и
+// позволяет указать уровень важности.
+func (l *Logger) Logf(level Level, format string, args ...interface{}) {
+	l.enqueue(Record{Level: level, Msg: fmt.Sprintf(format, args...)})
+}
+
+// LogFields работает как Logf, но дополнительно прикладывает структурированные
+// поля (учитываются JSONFormatter'ом, игнорируются TextFormatter'ом).
+func (l *Logger) LogFields(level Level, msg string, fields map[string]interface{}) {
+	l.enqueue(Record{Level: level, Msg: msg, Fields: fields})
+}
+
+// enqueue отбрасывает запись ниже MinLevel еще до попадания в канал (чтобы
+// отфильтрованные сообщения не расходовали буфер), затем отправляет ее в
+// logChan согласно l.mode.
+func (l *Logger) enqueue(rec Record) {
+	if rec.Level < l.minLevel {
+		return
+	}
+
 	l.mu.Lock()
-	defer l.mu.Unlock()
-	if l.closed {
-        fmt.Fprintf(os.Stderr, "Attempted to log after logger was closed: %s\n", message)
+	closed := l.closed
+	l.mu.Unlock()
+	if closed {
+        fmt.Fprintf(os.Stderr, "Attempted to log after logger was closed: %s\n", rec.Msg)
         return
     }
+
+	rec.Time = time.Now()
+
+	if l.mode == LogModeBlocking {
+		l.logChan <- rec
+		atomic.AddUint64(&l.writtenCount, 1)
+		return
+	}
+
 	select {
-	case l.logChan <- message:
-	default: 
-	fmt.Fprintf(os.Stderr, "Лог канал переполнен, пропускаем сообщение: %s\n", message)
+	case l.logChan <- rec:
+		atomic.AddUint64(&l.writtenCount, 1)
+	default:
+		atomic.AddUint64(&l.droppedCount, 1)
+		fmt.Fprintf(os.Stderr, "Лог канал переполнен, пропускаем сообщение: %s\n", rec.Msg)
+	}
+}
+
+// DroppedCount возвращает число сообщений, потерянных из-за переполнения
+// буфера (только в LogModeNonBlock - в LogModeBlocking всегда 0).
+func (l *Logger) DroppedCount() uint64 {
+	return atomic.LoadUint64(&l.droppedCount)
+}
+
+// WrittenCount возвращает число сообщений, успешно отправленных в канал
+// логгера. Сообщения, оставшиеся в канале при истечении дедлайна Shutdown,
+// тоже учтены здесь, хотя в файл не попадут (см. ShutdownTimeoutError).
+func (l *Logger) WrittenCount() uint64 {
+	return atomic.LoadUint64(&l.writtenCount)
+}
+
+// maybeReportDrops дописывает в сам лог итоговую строку вида
+// "[logger] dropped N messages", если с момента последнего отчета
+// накопились новые потери, а буфер канала только что опустел - так потеря
+// сообщений видна в самом логе, без необходимости отслеживать stderr.
+// Вызывается только из горутины Run, поэтому reportedDropped не требует
+// отдельной синхронизации.
+func (l *Logger) maybeReportDrops() {
+	if len(l.logChan) > 0 {
+		return
+	}
+	dropped := atomic.LoadUint64(&l.droppedCount)
+	if dropped <= l.reportedDropped {
+		return
+	}
+	newlyDropped := dropped - l.reportedDropped
+	l.reportedDropped = dropped
+	l.writeLogEntry(Record{
+		Time:  time.Now(),
+		Level: LevelWarn,
+		Msg:   fmt.Sprintf("[logger] dropped %d messages", newlyDropped),
+	})
+}
+
+// fanOutLine рассылает уже отформатированную строку лога всем живым
+// подписчикам (см. watch.go), не блокируя писателя: переполненному
+// подписчику сообщение просто роняется, а его собственный счетчик dropped
+// растет - аналогично monitor sink из hashicorp/consul.
+func (l *Logger) fanOutLine(line string) {
+	l.subMu.Lock()
+	defer l.subMu.Unlock()
+	for _, sub := range l.subscribers {
+		select {
+		case sub.ch <- line:
+		default:
+			atomic.AddUint64(&sub.dropped, 1)
+		}
+	}
+}
+
+// writeLogEntry форматирует запись через l.formatter, ротирует файл лога при
+// необходимости (вызывается только из горутины-писателя, поэтому доп.
+// синхронизация не нужна) и дописывает строку в файл.
+func (l *Logger) writeLogEntry(rec Record) {
+	logEntry := l.formatter.Format(rec) + "\n"
+	l.fanOutLine(logEntry)
+
+	if rotated, err := l.rotator.MaybeRotate(int64(len(logEntry))); err != nil {
+		fmt.Printf("Ошибка ротации лог файла %s: %v\n", l.filePath, err)
+	} else if rotated {
+		l.file.Close()
+		file, err := os.OpenFile(l.filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			fmt.Printf("Не удалось переоткрыть лог файл %s после ротации: %v\n", l.filePath, err)
+			return
+		}
+		l.file = file
+	}
+
+	if _, err := l.file.WriteString(logEntry); err != nil {
+		fmt.Printf("Ошибка записи в лог файл %s: %v\n", l.filePath, err)
 	}
 }
 
@@ -0,0 +1,118 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// defaultShutdownTimeout - таймаут Shutdown по умолчанию для
+// InstallSignalHandler, если вызывающий код не указал свой.
+const defaultShutdownTimeout = 2 * time.Second
+
+// ShutdownTimeoutError возвращается Shutdown, если дедлайн ctx истек раньше,
+// чем буфер канала удалось полностью дозаписать на диск. Remaining -
+// сколько сообщений так и осталось недозаписанными.
+type ShutdownTimeoutError struct {
+	Remaining int
+}
+
+func (e *ShutdownTimeoutError) Error() string {
+	return fmt.Sprintf("logger: дедлайн graceful shutdown истек, %d сообщени(е/й) не дозаписано", e.Remaining)
+}
+
+// Unwrap позволяет errors.Is(err, context.DeadlineExceeded) отличать таймаут
+// shutdown'а от прочих ошибок записи/fsync.
+func (e *ShutdownTimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// Shutdown реализует фазовую остановку логгера (по аналогии с log-gatherer'ом
+// pebble: текущий-флаш -> дренаж -> финальный флаш): (a) перестает принимать
+// новые сообщения через Log/Logf/LogFields, (b) дочитывает то, что уже
+// скопилось в канале, на диск, укладываясь в дедлайн ctx, и (c) fsync'ит и
+// закрывает файл. Если дедлайн истекает раньше, чем канал опустел, Shutdown
+// прерывает дренаж и возвращает *ShutdownTimeoutError с числом оставшихся
+// сообщений (через errors.Is он выглядит как context.DeadlineExceeded).
+//
+// Shutdown идемпотентен: повторный вызов после успешного завершения - no-op.
+func (l *Logger) Shutdown(ctx context.Context) error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	runStarted := l.runStarted
+	l.mu.Unlock()
+
+	if runStarted {
+		close(l.stopCh)
+		select {
+		case <-l.stoppedCh:
+		case <-ctx.Done():
+			return &ShutdownTimeoutError{Remaining: len(l.logChan)}
+		}
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			remaining := len(l.logChan)
+			if syncErr := l.file.Sync(); syncErr != nil {
+				fmt.Printf("Ошибка fsync файла %s при истечении дедлайна shutdown: %v\n", l.filePath, syncErr)
+			}
+			if closeErr := l.file.Close(); closeErr != nil {
+				fmt.Printf("Ошибка закрытия файла %s при истечении дедлайна shutdown: %v\n", l.filePath, closeErr)
+			}
+			return &ShutdownTimeoutError{Remaining: remaining}
+		}
+
+		select {
+		case rec, ok := <-l.logChan:
+			if !ok {
+				goto drained
+			}
+			l.writeLogEntry(rec)
+		default:
+			goto drained
+		}
+	}
+
+drained:
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("logger: не удалось fsync файл %s при shutdown: %w", l.filePath, err)
+	}
+	if err := l.file.Close(); err != nil {
+		return fmt.Errorf("logger: не удалось закрыть файл %s при shutdown: %w", l.filePath, err)
+	}
+	return nil
+}
+
+// InstallSignalHandler запускает горутину, которая слушает signals (по
+// умолчанию SIGINT и SIGTERM) и по получении любого из них вызывает
+// l.Shutdown с дедлайном timeout (по умолчанию defaultShutdownTimeout,
+// если timeout <= 0). Ошибка Shutdown печатается в stderr - решение о
+// завершении процесса (os.Exit) остается за вызывающим кодом.
+func (l *Logger) InstallSignalHandler(timeout time.Duration, signals ...os.Signal) {
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, signals...)
+
+	go func() {
+		<-sigCh
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if err := l.Shutdown(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "logger: graceful shutdown завершился с ошибкой: %v\n", err)
+		}
+	}()
+}
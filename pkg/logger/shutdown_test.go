@@ -0,0 +1,158 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLogger_ShutdownDrainsBufferAndClosesFile(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "shutdown_test.log")
+	var wg sync.WaitGroup
+
+	lg, err := NewLogger(logPath, 10, &wg)
+	if err != nil {
+		t.Fatalf("NewLogger failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	lg.Run(ctx)
+
+	lg.Log("message before shutdown")
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), time.Second)
+	defer shutdownCancel()
+	if err := lg.Shutdown(shutdownCtx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	// Повторный Log после Shutdown не должен паниковать и не должен попасть
+	// в файл.
+	lg.Log("message after shutdown")
+
+	logContent := readLogFile(t, logPath)
+	if !strings.Contains(logContent, "message before shutdown") {
+		t.Errorf("expected log to contain message written before Shutdown, got:\n%s", logContent)
+	}
+	if strings.Contains(logContent, "message after shutdown") {
+		t.Error("expected log to NOT contain message logged after Shutdown")
+	}
+
+	// Файл должен быть закрыт - повторная запись должна завершиться ошибкой.
+	if _, err := lg.file.WriteString("should fail"); err == nil {
+		t.Error("expected writing to the file after Shutdown to fail (file should be closed)")
+	}
+}
+
+func TestLogger_ShutdownIsIdempotent(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "idempotent_test.log")
+	var wg sync.WaitGroup
+
+	lg, err := NewLogger(logPath, 10, &wg)
+	if err != nil {
+		t.Fatalf("NewLogger failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	lg.Run(ctx)
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), time.Second)
+	defer shutdownCancel()
+	if err := lg.Shutdown(shutdownCtx); err != nil {
+		t.Fatalf("first Shutdown failed: %v", err)
+	}
+	if err := lg.Shutdown(shutdownCtx); err != nil {
+		t.Errorf("second Shutdown should be a no-op, got: %v", err)
+	}
+}
+
+func TestLogger_ShutdownReportsUndrainedCountOnDeadline(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "shutdown_timeout_test.log")
+	bufferSize := 20
+	var wg sync.WaitGroup
+
+	lg, err := NewLogger(logPath, bufferSize, &wg)
+	if err != nil {
+		t.Fatalf("NewLogger failed: %v", err)
+	}
+
+	// Не запускаем Run: сообщения просто копятся в канале, никто их не
+	// разбирает, так что Shutdown должен их дренировать сам.
+	for i := 0; i < bufferSize; i++ {
+		lg.Log(fmt.Sprintf("buffered message %d", i))
+	}
+
+	alreadyExpired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	err = lg.Shutdown(alreadyExpired)
+	if err == nil {
+		t.Fatal("expected Shutdown to report a deadline error, got nil")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected errors.Is(err, context.DeadlineExceeded) to be true, got err: %v", err)
+	}
+	var timeoutErr *ShutdownTimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("expected a *ShutdownTimeoutError, got %T: %v", err, err)
+	}
+	if timeoutErr.Remaining != bufferSize {
+		t.Errorf("expected Remaining %d (nothing drained), got %d", bufferSize, timeoutErr.Remaining)
+	}
+}
+
+func TestLogger_InstallSignalHandlerTriggersShutdown(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "signal_test.log")
+	var wg sync.WaitGroup
+
+	lg, err := NewLogger(logPath, 10, &wg)
+	if err != nil {
+		t.Fatalf("NewLogger failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	lg.Run(ctx)
+
+	lg.Log("before signal")
+	lg.InstallSignalHandler(time.Second, os.Interrupt)
+
+	proc, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatalf("FindProcess failed: %v", err)
+	}
+	if err := proc.Signal(os.Interrupt); err != nil {
+		t.Fatalf("failed to send self-signal: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		lg.mu.Lock()
+		closed := lg.closed
+		lg.mu.Unlock()
+		if closed {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for InstallSignalHandler to invoke Shutdown")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	logContent := readLogFile(t, logPath)
+	if !strings.Contains(logContent, "before signal") {
+		t.Errorf("expected log to contain message written before the signal, got:\n%s", logContent)
+	}
+}
@@ -0,0 +1,191 @@
+package logger
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// defaultSubscriberBuffer - размер буфера канала одного подписчика Subscribe,
+// аналогично defaultSubscriberBuffer в pkg/events.
+const defaultSubscriberBuffer = 64
+
+// logSubscriber - внутреннее состояние одного подписчика на поток строк
+// лога, см. Logger.Subscribe.
+type logSubscriber struct {
+	ch      chan string
+	dropped uint64
+}
+
+// Subscribe регистрирует нового подписчика на уже отформатированные строки
+// лога (ровно то, что пишется в файл в writeLogEntry) и возвращает канал для
+// чтения и cancel, отменяющий подписку. Подписка также автоматически
+// снимается при отмене ctx.
+func (l *Logger) Subscribe(ctx context.Context) (<-chan string, func()) {
+	return l.SubscribeWithBuffer(ctx, defaultSubscriberBuffer)
+}
+
+// SubscribeWithBuffer - то же самое, что и Subscribe, но позволяет задать
+// размер буфера канала подписчика явно (например, побольше для заведомо
+// быстрого потребителя).
+func (l *Logger) SubscribeWithBuffer(ctx context.Context, bufferSize int) (<-chan string, func()) {
+	l.subMu.Lock()
+	id := l.nextSubID
+	l.nextSubID++
+	sub := &logSubscriber{ch: make(chan string, bufferSize)}
+	l.subscribers[id] = sub
+	l.subMu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			l.subMu.Lock()
+			delete(l.subscribers, id)
+			l.subMu.Unlock()
+			close(sub.ch)
+		})
+	}
+
+	go func() {
+		<-ctx.Done()
+		cancel()
+	}()
+
+	return sub.ch, cancel
+}
+
+// ReadConfig - параметры ReadLogs: Tail>0 значит "сначала отдать последние
+// Tail строк из файла на диске", Follow значит "затем продолжать стримить
+// новые строки live через Subscribe".
+type ReadConfig struct {
+	Tail   int
+	Follow bool
+}
+
+// LogWatcher - результат ReadLogs: Msg отдает строки лога, Err - ошибки
+// чтения файла при воспроизведении хвоста, ConsumerGone закрывается, когда
+// внутренняя горутина LogWatcher завершилась (ctx отменен; без Follow - сразу
+// после воспроизведения хвоста; в режиме Follow - когда подписка закрылась).
+type LogWatcher struct {
+	Msg <-chan string
+	Err <-chan error
+
+	cancel func()
+	done   chan struct{}
+}
+
+// ConsumerGone возвращает канал, закрываемый, когда LogWatcher завершил
+// работу и больше не будет писать в Msg/Err.
+func (w *LogWatcher) ConsumerGone() <-chan struct{} {
+	return w.done
+}
+
+// Stop останавливает LogWatcher, если он еще не завершился сам.
+func (w *LogWatcher) Stop() {
+	if w.cancel != nil {
+		w.cancel()
+	}
+}
+
+// ReadLogs воспроизводит последние config.Tail строк файла лога (если >0) и,
+// если config.Follow, продолжает стримить новые строки живьем через
+// Subscribe. Предназначен для админ-CLI или HTTP-эндпоинта, которым нужно
+// "посмотреть хвост лога".
+func (l *Logger) ReadLogs(ctx context.Context, config ReadConfig) (*LogWatcher, error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	msgCh := make(chan string, defaultSubscriberBuffer)
+	errCh := make(chan error, 1)
+	done := make(chan struct{})
+
+	w := &LogWatcher{
+		Msg:    msgCh,
+		Err:    errCh,
+		cancel: cancel,
+		done:   done,
+	}
+
+	// Подписка оформляется до возврата из ReadLogs, чтобы строки, записанные
+	// сразу после вызова, не терялись, пока горутина воспроизводит хвост.
+	var liveCh <-chan string
+	var unsubscribe func()
+	if config.Follow {
+		liveCh, unsubscribe = l.Subscribe(ctx)
+	}
+
+	go func() {
+		defer close(done)
+		defer close(msgCh)
+		defer close(errCh)
+		defer cancel()
+
+		if config.Tail > 0 {
+			lines, err := tailFile(l.filePath, config.Tail)
+			if err != nil {
+				select {
+				case errCh <- err:
+				case <-ctx.Done():
+					return
+				}
+			}
+			for _, line := range lines {
+				select {
+				case msgCh <- line:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+
+		if !config.Follow {
+			return
+		}
+
+		defer unsubscribe()
+
+		for {
+			select {
+			case line, ok := <-liveCh:
+				if !ok {
+					return
+				}
+				select {
+				case msgCh <- line:
+				default:
+					// Потребитель не успевает - не блокируем подписку,
+					// пропускаем строку (см. ConsumerGone).
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return w, nil
+}
+
+// tailFile возвращает последние n строк файла path (или все строки, если их
+// меньше n).
+func tailFile(path string, n int) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть файл лога %s для чтения хвоста: %w", path, err)
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+		if len(lines) > n {
+			lines = lines[1:]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла лога %s: %w", path, err)
+	}
+
+	return lines, nil
+}
@@ -0,0 +1,205 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLogger_SubscribeDeliversLiveLines(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "subscribe_test.log")
+	var wg sync.WaitGroup
+
+	lg, err := NewLogger(logPath, 10, &wg)
+	if err != nil {
+		t.Fatalf("NewLogger failed: %v", err)
+	}
+
+	runCtx, runCancel := context.WithCancel(context.Background())
+	defer runCancel()
+	lg.Run(runCtx)
+
+	subCtx, unsubscribe := context.WithCancel(context.Background())
+	defer unsubscribe()
+	ch, _ := lg.Subscribe(subCtx)
+
+	lg.Log("hello subscriber")
+
+	select {
+	case line := <-ch:
+		if !strings.Contains(line, "hello subscriber") {
+			t.Errorf("unexpected line from subscriber: %q", line)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for subscribed log line")
+	}
+}
+
+func TestLogger_SubscribeCancelReleasesChannel(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "cancel_test.log")
+	var wg sync.WaitGroup
+
+	lg, err := NewLogger(logPath, 10, &wg)
+	if err != nil {
+		t.Fatalf("NewLogger failed: %v", err)
+	}
+
+	runCtx, runCancel := context.WithCancel(context.Background())
+	defer runCancel()
+	lg.Run(runCtx)
+
+	subCtx, unsubscribe := context.WithCancel(context.Background())
+	ch, _ := lg.Subscribe(subCtx)
+
+	lg.subMu.Lock()
+	subCount := len(lg.subscribers)
+	lg.subMu.Unlock()
+	if subCount != 1 {
+		t.Fatalf("expected 1 subscriber after Subscribe, got %d", subCount)
+	}
+
+	unsubscribe()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		lg.subMu.Lock()
+		subCount = len(lg.subscribers)
+		lg.subMu.Unlock()
+		if subCount == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for context cancellation to release subscriber")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if _, ok := <-ch; ok {
+		t.Error("expected subscriber channel to be closed after context cancellation")
+	}
+}
+
+func TestLogger_SlowSubscriberDropsOwnLinesOnly(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "slow_subscriber_test.log")
+	var wg sync.WaitGroup
+
+	lg, err := NewLogger(logPath, 100, &wg)
+	if err != nil {
+		t.Fatalf("NewLogger failed: %v", err)
+	}
+
+	runCtx, runCancel := context.WithCancel(context.Background())
+	defer runCancel()
+	lg.Run(runCtx)
+
+	slowCtx, cancelSlow := context.WithCancel(context.Background())
+	defer cancelSlow()
+	slow, _ := lg.Subscribe(slowCtx)
+
+	// Быстрому подписчику даем буфер с запасом, чтобы проверка его "быстроты"
+	// не зависела от того, успевает ли его читающая горутина быть
+	// запланирована раньше, чем писатель зальет весь буфер.
+	const total = defaultSubscriberBuffer + 10
+	fastCtx, cancelFast := context.WithCancel(context.Background())
+	defer cancelFast()
+	fast, _ := lg.SubscribeWithBuffer(fastCtx, total)
+
+	// Медленный подписчик не читает канал вовсе, переполняя свой буфер, пока
+	// быстрый читает все подряд.
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < total; i++ {
+			<-fast
+		}
+		close(done)
+	}()
+
+	for i := 0; i < total; i++ {
+		lg.Log(fmt.Sprintf("message %d", i))
+	}
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for fast subscriber to drain")
+	}
+
+	drained := 0
+	for {
+		select {
+		case <-slow:
+			drained++
+		default:
+			goto doneSlow
+		}
+	}
+doneSlow:
+	if drained >= total {
+		t.Errorf("expected slow subscriber to have dropped some lines, drained all %d", drained)
+	}
+}
+
+func TestLogger_ReadLogsTailThenFollow(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "readlogs_test.log")
+	var wg sync.WaitGroup
+
+	lg, err := NewLogger(logPath, 10, &wg)
+	if err != nil {
+		t.Fatalf("NewLogger failed: %v", err)
+	}
+
+	runCtx, runCancel := context.WithCancel(context.Background())
+	defer runCancel()
+	lg.Run(runCtx)
+
+	lg.Log("past message 1")
+	lg.Log("past message 2")
+	time.Sleep(50 * time.Millisecond)
+
+	readCtx, readCancel := context.WithCancel(context.Background())
+	defer readCancel()
+	watcher, err := lg.ReadLogs(readCtx, ReadConfig{Tail: 10, Follow: true})
+	if err != nil {
+		t.Fatalf("ReadLogs failed: %v", err)
+	}
+
+	var lines []string
+	collectDeadline := time.After(2 * time.Second)
+	lg.Log("live message")
+
+collectLoop:
+	for {
+		select {
+		case line, ok := <-watcher.Msg:
+			if !ok {
+				break collectLoop
+			}
+			lines = append(lines, line)
+			joined := strings.Join(lines, "\n")
+			if strings.Contains(joined, "past message 1") &&
+				strings.Contains(joined, "past message 2") &&
+				strings.Contains(joined, "live message") {
+				break collectLoop
+			}
+		case err := <-watcher.Err:
+			t.Fatalf("unexpected error from ReadLogs: %v", err)
+		case <-collectDeadline:
+			t.Fatalf("timed out collecting lines, got so far: %+v", lines)
+		}
+	}
+
+	readCancel()
+	select {
+	case <-watcher.ConsumerGone():
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for LogWatcher to stop after context cancellation")
+	}
+}
@@ -0,0 +1,226 @@
+// Package logrotate реализует ротацию одного файла (лога или архива) по
+// размеру или возрасту, с опциональным сжатием старых бэкапов.
+package logrotate
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// RotateConfig описывает политику ротации для одного файла.
+// Нулевое значение означает "ротация отключена".
+type RotateConfig struct {
+	MaxSizeBytes int64         // 0 = не ротировать по размеру
+	MaxAge       time.Duration // 0 = не ротировать по возрасту
+	MaxBackups   int           // сколько ротированных копий хранить (0 = не хранить, просто обрезать файл)
+	Compress     bool          // сжимать ротированные бэкапы gzip'ом
+	MaxBackupAge time.Duration // 0 = не ограничивать; иначе бэкапы старше этого возраста удаляются при каждой ротации, независимо от MaxBackups
+}
+
+// Enabled сообщает, задана ли хоть одна политика ротации.
+func (c RotateConfig) Enabled() bool {
+	return c.MaxSizeBytes > 0 || c.MaxAge > 0
+}
+
+// Rotator следит за одним файлом и ротирует его согласно RotateConfig.
+// Один Rotator не потокобезопасен сам по себе - вызывающий код должен
+// сериализовать обращения к нему (как это делает логгер со своей единственной
+// горутиной-писателем или архиватор под своим file-мьютексом).
+type Rotator struct {
+	path      string
+	cfg       RotateConfig
+	startedAt time.Time
+}
+
+// NewRotator создает Rotator для path. При старте сканирует уже существующий
+// файл (если есть), чтобы восстановить "возраст" текущего файла после
+// перезапуска процесса - отдельный state-файл для этого не нужен.
+func NewRotator(path string, cfg RotateConfig) *Rotator {
+	r := &Rotator{path: path, cfg: cfg}
+	if info, err := os.Stat(path); err == nil {
+		r.startedAt = info.ModTime()
+	} else {
+		r.startedAt = time.Now()
+	}
+	return r
+}
+
+// MaybeRotate проверяет текущий размер/возраст файла и, если политика
+// нарушена, ротирует его. nextWrite - размер данных, которые вызывающий
+// код собирается дописать следующим; он учитывается вместе с текущим
+// размером файла, чтобы ротация происходила до переполнения лимита.
+func (r *Rotator) MaybeRotate(nextWrite int64) (bool, error) {
+	if !r.cfg.Enabled() {
+		return false, nil
+	}
+
+	info, err := os.Stat(r.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("не удалось получить информацию о файле %s: %w", r.path, err)
+	}
+
+	needRotate := false
+	if r.cfg.MaxSizeBytes > 0 && info.Size()+nextWrite > r.cfg.MaxSizeBytes {
+		needRotate = true
+	}
+	if r.cfg.MaxAge > 0 && time.Since(r.startedAt) >= r.cfg.MaxAge {
+		needRotate = true
+	}
+	if !needRotate {
+		return false, nil
+	}
+
+	return true, r.Rotate()
+}
+
+// Rotate безусловно ротирует файл: <name> -> <name>.1 -> <name>.2 -> ...,
+// отбрасывая самый старый бэкап сверх MaxBackups и, при необходимости,
+// сжимая свежий бэкап.
+func (r *Rotator) Rotate() error {
+	if _, err := os.Stat(r.path); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("не удалось получить информацию о файле %s: %w", r.path, err)
+	}
+
+	if r.cfg.MaxBackups <= 0 {
+		// Бэкапы не хранятся - просто обрезаем файл на месте.
+		if err := os.Truncate(r.path, 0); err != nil {
+			return fmt.Errorf("не удалось обрезать файл %s при ротации: %w", r.path, err)
+		}
+		r.startedAt = time.Now()
+		return nil
+	}
+
+	// Сдвигаем существующие бэкапы, начиная со старшего: .N-1 -> .N.
+	for i := r.cfg.MaxBackups; i >= 1; i-- {
+		src, srcIsGz := r.findBackup(i)
+		if src == "" {
+			continue
+		}
+		if i == r.cfg.MaxBackups {
+			if err := os.Remove(src); err != nil {
+				return fmt.Errorf("не удалось удалить устаревший бэкап %s: %w", src, err)
+			}
+			continue
+		}
+		dst := r.backupName(i+1, srcIsGz)
+		if err := os.Rename(src, dst); err != nil {
+			return fmt.Errorf("не удалось переименовать бэкап %s в %s: %w", src, dst, err)
+		}
+	}
+
+	firstBackup := r.backupName(1, false)
+	if err := os.Rename(r.path, firstBackup); err != nil {
+		return fmt.Errorf("не удалось переименовать %s в %s при ротации: %w", r.path, firstBackup, err)
+	}
+
+	if r.cfg.Compress {
+		if err := compressFile(firstBackup); err != nil {
+			return fmt.Errorf("не удалось сжать бэкап %s: %w", firstBackup, err)
+		}
+	}
+
+	if err := r.pruneAgedBackups(); err != nil {
+		return err
+	}
+
+	r.startedAt = time.Now()
+	return nil
+}
+
+// pruneAgedBackups удаляет бэкапы r.path, чья mtime старше r.cfg.MaxBackupAge,
+// независимо от их индекса - это отдельная от MaxBackups политика хранения
+// (MaxBackups ограничивает количество, MaxBackupAge - возраст).
+func (r *Rotator) pruneAgedBackups() error {
+	if r.cfg.MaxBackupAge <= 0 {
+		return nil
+	}
+
+	matches, err := filepath.Glob(r.path + ".*")
+	if err != nil {
+		return fmt.Errorf("не удалось перечислить бэкапы %s: %w", r.path, err)
+	}
+
+	cutoff := time.Now().Add(-r.cfg.MaxBackupAge)
+	for _, m := range matches {
+		info, err := os.Stat(m)
+		if err != nil {
+			continue
+		}
+		if info.ModTime().Before(cutoff) {
+			if err := os.Remove(m); err != nil {
+				return fmt.Errorf("не удалось удалить устаревший бэкап %s: %w", m, err)
+			}
+		}
+	}
+	return nil
+}
+
+// backupName строит путь для бэкапа с индексом i, добавляя ".gz" если gz.
+func (r *Rotator) backupName(i int, gz bool) string {
+	name := fmt.Sprintf("%s.%d", r.path, i)
+	if gz {
+		name += ".gz"
+	}
+	return name
+}
+
+// findBackup ищет бэкап с индексом i на диске, в сжатом или обычном виде,
+// и возвращает его путь (пустая строка, если такого бэкапа нет).
+func (r *Rotator) findBackup(i int) (path string, isGz bool) {
+	gzPath := r.backupName(i, true)
+	if _, err := os.Stat(gzPath); err == nil {
+		return gzPath, true
+	}
+	plainPath := r.backupName(i, false)
+	if _, err := os.Stat(plainPath); err == nil {
+		return plainPath, false
+	}
+	return "", false
+}
+
+// compressFile сжимает path в path+".gz" и удаляет несжатый оригинал.
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("не удалось открыть %s для сжатия: %w", path, err)
+	}
+	defer src.Close()
+
+	gzPath := path + ".gz"
+	dst, err := os.Create(gzPath)
+	if err != nil {
+		return fmt.Errorf("не удалось создать %s: %w", gzPath, err)
+	}
+
+	gzw := gzip.NewWriter(dst)
+	if _, err := io.Copy(gzw, src); err != nil {
+		gzw.Close()
+		dst.Close()
+		os.Remove(gzPath)
+		return fmt.Errorf("не удалось записать сжатые данные в %s: %w", gzPath, err)
+	}
+	if err := gzw.Close(); err != nil {
+		dst.Close()
+		os.Remove(gzPath)
+		return fmt.Errorf("не удалось закрыть gzip writer для %s: %w", gzPath, err)
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(gzPath)
+		return fmt.Errorf("не удалось закрыть %s: %w", gzPath, err)
+	}
+
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("не удалось удалить несжатый бэкап %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package logrotate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMaybeRotate_Disabled(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.log")
+	if err := os.WriteFile(path, []byte("hello"), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	r := NewRotator(path, RotateConfig{})
+	rotated, err := r.MaybeRotate(1024)
+	if err != nil {
+		t.Fatalf("MaybeRotate failed: %v", err)
+	}
+	if rotated {
+		t.Error("expected no rotation with empty RotateConfig")
+	}
+}
+
+func TestRotate_ShiftsBackups(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.log")
+	if err := os.WriteFile(path, []byte("current"), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	if err := os.WriteFile(path+".1", []byte("backup1"), 0644); err != nil {
+		t.Fatalf("failed to create backup: %v", err)
+	}
+
+	r := NewRotator(path, RotateConfig{MaxBackups: 2})
+	if err := r.Rotate(); err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("expected original file to be gone after rotation")
+	}
+	if content, err := os.ReadFile(path + ".1"); err != nil || string(content) != "current" {
+		t.Errorf("expected %s.1 to contain 'current', got %q (err: %v)", path, content, err)
+	}
+	if content, err := os.ReadFile(path + ".2"); err != nil || string(content) != "backup1" {
+		t.Errorf("expected %s.2 to contain 'backup1', got %q (err: %v)", path, content, err)
+	}
+}
+
+func TestRotate_DropsOldestBeyondMaxBackups(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.log")
+	if err := os.WriteFile(path, []byte("current"), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	if err := os.WriteFile(path+".1", []byte("oldest"), 0644); err != nil {
+		t.Fatalf("failed to create backup: %v", err)
+	}
+
+	r := NewRotator(path, RotateConfig{MaxBackups: 1})
+	if err := r.Rotate(); err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+
+	if _, err := os.Stat(path + ".1"); err != nil {
+		t.Errorf("expected %s.1 to exist, got err: %v", path, err)
+	}
+	if _, err := os.Stat(path + ".2"); !os.IsNotExist(err) {
+		t.Errorf("expected %s.2 to not exist (MaxBackups=1), got err: %v", path, err)
+	}
+}
+
+func TestRotate_Compress(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.log")
+	if err := os.WriteFile(path, []byte("to be compressed"), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	r := NewRotator(path, RotateConfig{MaxBackups: 1, Compress: true})
+	if err := r.Rotate(); err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+
+	if _, err := os.Stat(path + ".1.gz"); err != nil {
+		t.Errorf("expected compressed backup %s.1.gz to exist, got err: %v", path, err)
+	}
+	if _, err := os.Stat(path + ".1"); !os.IsNotExist(err) {
+		t.Error("expected uncompressed backup to be removed after compression")
+	}
+}
+
+func TestRotate_PrunesBackupsOlderThanMaxBackupAge(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.log")
+	if err := os.WriteFile(path, []byte("current"), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	if err := os.WriteFile(path+".2", []byte("ancient"), 0644); err != nil {
+		t.Fatalf("failed to create backup: %v", err)
+	}
+	old := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(path+".2", old, old); err != nil {
+		t.Fatalf("failed to set mtime: %v", err)
+	}
+
+	r := NewRotator(path, RotateConfig{MaxBackups: 5, MaxBackupAge: 24 * time.Hour})
+	if err := r.Rotate(); err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+
+	if _, err := os.Stat(path + ".1"); err != nil {
+		t.Errorf("expected freshly rotated backup %s.1 to exist, got err: %v", path, err)
+	}
+	// Ротация сдвигает старый .2 в .3 (mtime сохраняется), и уже там его
+	// удаляет проверка возраста.
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected ancient backup (shifted to %s.3) to be pruned for exceeding MaxBackupAge, got err: %v", path, err)
+	}
+}
+
+func TestMaybeRotate_BySize(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.log")
+	if err := os.WriteFile(path, []byte("12345678"), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	r := NewRotator(path, RotateConfig{MaxSizeBytes: 10, MaxBackups: 1})
+	rotated, err := r.MaybeRotate(5)
+	if err != nil {
+		t.Fatalf("MaybeRotate failed: %v", err)
+	}
+	if !rotated {
+		t.Error("expected rotation when current size + next write exceeds MaxSizeBytes")
+	}
+	if _, err := os.Stat(path + ".1"); err != nil {
+		t.Errorf("expected backup after rotation, got err: %v", err)
+	}
+}
+
+func TestMaybeRotate_ByAge(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.log")
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatalf("failed to set mtime: %v", err)
+	}
+
+	r := NewRotator(path, RotateConfig{MaxAge: time.Minute, MaxBackups: 1})
+	rotated, err := r.MaybeRotate(0)
+	if err != nil {
+		t.Fatalf("MaybeRotate failed: %v", err)
+	}
+	if !rotated {
+		t.Error("expected rotation when file is older than MaxAge")
+	}
+}
@@ -0,0 +1,143 @@
+// Package result хранит результаты/заметки задач в файлах-компаньонах
+// (sidecar) рядом с основным хранилищем: storage/results/<id>.log для
+// активных задач, storage/archive-results/<id>.log для заархивированных.
+package result
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Writer дописывает куски результата задачи в sidecar-файл (через
+// O_APPEND, так что несколько горутин могут писать безопасно) и буферизирует
+// их в памяти для быстрого чтения без повторного обращения к диску.
+type Writer struct {
+	path  string
+	mutex *sync.Mutex
+	buf   []byte
+}
+
+// Write реализует io.Writer: дописывает p в sidecar-файл и в буфер.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(w.path), 0755); err != nil {
+		return 0, fmt.Errorf("не удалось создать директорию для sidecar-файла %s: %w", w.path, err)
+	}
+
+	file, err := os.OpenFile(w.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось открыть sidecar-файл %s: %w", w.path, err)
+	}
+	defer file.Close()
+
+	n, err := file.Write(p)
+	if err != nil {
+		return n, fmt.Errorf("ошибка записи в sidecar-файл %s: %w", w.path, err)
+	}
+	w.buf = append(w.buf, p[:n]...)
+	return n, nil
+}
+
+// Buffered возвращает то, что было записано через этот Writer с момента его создания.
+func (w *Writer) Buffered() []byte {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	out := make([]byte, len(w.buf))
+	copy(out, w.buf)
+	return out
+}
+
+// Registry раздает Writer на sidecar-файлы задач в заданной директории,
+// гарантируя, что конкурентные записи в файл одного и того же ID
+// сериализуются через персональный sync.Mutex, а не блокируют друг друга.
+type Registry struct {
+	dir   string
+	mu    sync.RWMutex
+	locks map[int]*sync.Mutex
+}
+
+// NewRegistry создает реестр sidecar-файлов результатов в директории dir.
+func NewRegistry(dir string) *Registry {
+	return &Registry{
+		dir:   dir,
+		locks: make(map[int]*sync.Mutex),
+	}
+}
+
+func (r *Registry) lockFor(id int) *sync.Mutex {
+	r.mu.RLock()
+	l, ok := r.locks[id]
+	r.mu.RUnlock()
+	if ok {
+		return l
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if l, ok := r.locks[id]; ok {
+		return l
+	}
+	l = &sync.Mutex{}
+	r.locks[id] = l
+	return l
+}
+
+// Path возвращает путь к sidecar-файлу задачи id.
+func (r *Registry) Path(id int) string {
+	return filepath.Join(r.dir, fmt.Sprintf("%d.log", id))
+}
+
+// Writer возвращает io.Writer для дозаписи результата задачи id.
+func (r *Registry) Writer(id int) *Writer {
+	return &Writer{
+		path:  r.Path(id),
+		mutex: r.lockFor(id),
+	}
+}
+
+// Read читает содержимое sidecar-файла задачи id целиком. Отсутствие файла -
+// не ошибка, возвращается nil.
+func (r *Registry) Read(id int) ([]byte, error) {
+	data, err := os.ReadFile(r.Path(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("не удалось прочитать sidecar-файл задачи %d: %w", id, err)
+	}
+	return data, nil
+}
+
+// Delete удаляет sidecar-файл задачи id. Отсутствие файла - не ошибка.
+func (r *Registry) Delete(id int) error {
+	if err := os.Remove(r.Path(id)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("не удалось удалить sidecar-файл задачи %d: %w", id, err)
+	}
+	return nil
+}
+
+// CopyTo копирует sidecar-файл задачи id в другой реестр dest. Отсутствие
+// исходного файла - не ошибка, просто ничего не делается.
+func (r *Registry) CopyTo(id int, dest *Registry) error {
+	data, err := r.Read(id)
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(dest.dir, 0755); err != nil {
+		return fmt.Errorf("не удалось создать директорию %s: %w", dest.dir, err)
+	}
+
+	destPath := dest.Path(id)
+	if err := os.WriteFile(destPath, data, 0644); err != nil {
+		return fmt.Errorf("не удалось скопировать sidecar-файл задачи %d в %s: %w", id, destPath, err)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package result
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestWriter_WritesAndBuffers(t *testing.T) {
+	dir := t.TempDir()
+	reg := NewRegistry(dir)
+
+	w := reg.Writer(1)
+	if _, err := w.Write([]byte("hello ")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if _, err := w.Write([]byte("world")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	if got := string(w.Buffered()); got != "hello world" {
+		t.Errorf("expected buffered %q, got %q", "hello world", got)
+	}
+
+	data, err := os.ReadFile(reg.Path(1))
+	if err != nil {
+		t.Fatalf("failed to read sidecar file: %v", err)
+	}
+	if string(data) != "hello world" {
+		t.Errorf("expected file content %q, got %q", "hello world", string(data))
+	}
+}
+
+func TestRegistry_ConcurrentWritesSameID(t *testing.T) {
+	dir := t.TempDir()
+	reg := NewRegistry(dir)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := reg.Writer(7)
+			_, _ = w.Write([]byte("x"))
+		}()
+	}
+	wg.Wait()
+
+	data, err := reg.Read(7)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(data) != 20 {
+		t.Errorf("expected 20 bytes written without interleaving corruption, got %d: %q", len(data), data)
+	}
+}
+
+func TestRegistry_ReadMissingReturnsNil(t *testing.T) {
+	reg := NewRegistry(t.TempDir())
+	data, err := reg.Read(42)
+	if err != nil {
+		t.Fatalf("expected no error for missing sidecar, got %v", err)
+	}
+	if data != nil {
+		t.Errorf("expected nil data for missing sidecar, got %q", data)
+	}
+}
+
+func TestRegistry_Delete(t *testing.T) {
+	dir := t.TempDir()
+	reg := NewRegistry(dir)
+	w := reg.Writer(3)
+	_, _ = w.Write([]byte("note"))
+
+	if err := reg.Delete(3); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := os.Stat(reg.Path(3)); !os.IsNotExist(err) {
+		t.Errorf("expected sidecar file to be removed")
+	}
+
+	// Повторное удаление отсутствующего файла - не ошибка
+	if err := reg.Delete(3); err != nil {
+		t.Errorf("expected no error deleting already-absent sidecar, got %v", err)
+	}
+}
+
+func TestRegistry_CopyTo(t *testing.T) {
+	srcDir := t.TempDir()
+	dstDir := filepath.Join(t.TempDir(), "nested")
+	src := NewRegistry(srcDir)
+	dst := NewRegistry(dstDir)
+
+	w := src.Writer(5)
+	_, _ = w.Write([]byte("archived note"))
+
+	if err := src.CopyTo(5, dst); err != nil {
+		t.Fatalf("CopyTo failed: %v", err)
+	}
+
+	data, err := dst.Read(5)
+	if err != nil {
+		t.Fatalf("Read from dest failed: %v", err)
+	}
+	if string(data) != "archived note" {
+		t.Errorf("expected copied content %q, got %q", "archived note", string(data))
+	}
+}
+
+func TestRegistry_CopyToMissingIsNoop(t *testing.T) {
+	src := NewRegistry(t.TempDir())
+	dst := NewRegistry(t.TempDir())
+
+	if err := src.CopyTo(99, dst); err != nil {
+		t.Fatalf("expected no error copying missing sidecar, got %v", err)
+	}
+	if _, err := os.Stat(dst.Path(99)); !os.IsNotExist(err) {
+		t.Errorf("expected no file created at destination for missing source")
+	}
+}
@@ -0,0 +1,97 @@
+// Package retry реализует повтор операции с экспоненциальной задержкой и
+// полным джиттером для транзиентных ошибок ввода-вывода (временно
+// заблокированный файл, диск, который вот-вот восстановится, и т.п.).
+package retry
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Policy описывает политику повторов: сколько раз пробовать, с какой
+// начальной задержкой и как она растет.
+type Policy struct {
+	MaxAttempts int           // сколько раз вызвать op (1 = без повторов)
+	BaseDelay   time.Duration // задержка перед первым повтором
+	Factor      float64       // во сколько раз растет задержка с каждой попыткой
+	MaxDelay    time.Duration // верхняя граница задержки (0 = без ограничения)
+	// OnRetry, если задан, вызывается после каждой неудачной попытки (кроме
+	// последней) с номером попытки (начиная с 1) и ошибкой - используется,
+	// например, для логирования.
+	OnRetry func(attempt int, err error)
+}
+
+// sleepFunc и randFloat - точки внедрения для тестов: можно подменить
+// "засыпание" и источник джиттера, не дожидаясь реального time.Sleep.
+var (
+	sleepFunc = realSleep
+	randFloat = rand.Float64
+)
+
+func realSleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Do выполняет op, повторяя при ошибке согласно policy. Задержка между
+// попытками вычисляется как rand(0, min(MaxDelay, BaseDelay * Factor^attempt))
+// (полный джиттер), где attempt - номер повтора, начиная с 0. Если ctx
+// отменяется во время ожидания, Do возвращает ctx.Err() немедленно, не
+// дожидаясь конца сна.
+func Do(ctx context.Context, policy Policy, op func() error) error {
+	maxAttempts := policy.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		lastErr = op()
+		if lastErr == nil {
+			return nil
+		}
+
+		if attempt == maxAttempts {
+			break
+		}
+
+		if policy.OnRetry != nil {
+			policy.OnRetry(attempt, lastErr)
+		}
+
+		if err := sleepFunc(ctx, backoffDelay(policy, attempt-1)); err != nil {
+			return err
+		}
+	}
+
+	return fmt.Errorf("retry: операция не выполнена после %d попыток, последняя ошибка: %w", maxAttempts, lastErr)
+}
+
+// backoffDelay вычисляет верхнюю границу задержки для данного номера повтора
+// (attempt считается от 0) и берет случайное значение в [0, граница).
+func backoffDelay(policy Policy, attempt int) time.Duration {
+	upper := float64(policy.BaseDelay) * math.Pow(policy.Factor, float64(attempt))
+	if policy.MaxDelay > 0 && upper > float64(policy.MaxDelay) {
+		upper = float64(policy.MaxDelay)
+	}
+	if upper <= 0 {
+		return 0
+	}
+	return time.Duration(randFloat() * upper)
+}
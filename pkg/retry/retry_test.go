@@ -0,0 +1,155 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// withFakeSleeper подменяет sleepFunc и randFloat на детерминированные
+// заглушки на время теста и возвращает функцию восстановления оригиналов.
+func withFakeSleeper(t *testing.T, recorded *[]time.Duration, jitter float64) func() {
+	t.Helper()
+	origSleep := sleepFunc
+	origRand := randFloat
+	sleepFunc = func(ctx context.Context, d time.Duration) error {
+		*recorded = append(*recorded, d)
+		return nil
+	}
+	randFloat = func() float64 { return jitter }
+	return func() {
+		sleepFunc = origSleep
+		randFloat = origRand
+	}
+}
+
+func TestDo_SucceedsFirstTry(t *testing.T) {
+	var delays []time.Duration
+	defer withFakeSleeper(t, &delays, 1)()
+
+	calls := 0
+	err := Do(context.Background(), Policy{MaxAttempts: 3, BaseDelay: time.Millisecond}, func() error {
+		calls++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 call, got %d", calls)
+	}
+	if len(delays) != 0 {
+		t.Errorf("expected no sleeps, got %v", delays)
+	}
+}
+
+func TestDo_RetriesThenSucceeds(t *testing.T) {
+	var delays []time.Duration
+	defer withFakeSleeper(t, &delays, 1)()
+
+	var retried []int
+	calls := 0
+	err := Do(context.Background(), Policy{
+		MaxAttempts: 5,
+		BaseDelay:   time.Millisecond,
+		Factor:      2,
+		OnRetry: func(attempt int, err error) {
+			retried = append(retried, attempt)
+		},
+	}, func() error {
+		calls++
+		if calls < 3 {
+			return errors.New("transient")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 calls, got %d", calls)
+	}
+	if len(retried) != 2 || retried[0] != 1 || retried[1] != 2 {
+		t.Errorf("expected OnRetry(1), OnRetry(2), got %v", retried)
+	}
+	// С jitter=1 задержки должны точно равняться верхней границе base*factor^attempt
+	want := []time.Duration{time.Millisecond, 2 * time.Millisecond}
+	if len(delays) != len(want) || delays[0] != want[0] || delays[1] != want[1] {
+		t.Errorf("expected delays %v, got %v", want, delays)
+	}
+}
+
+func TestDo_ExhaustsAttempts(t *testing.T) {
+	var delays []time.Duration
+	defer withFakeSleeper(t, &delays, 1)()
+
+	calls := 0
+	wantErr := errors.New("always fails")
+	err := Do(context.Background(), Policy{MaxAttempts: 3, BaseDelay: time.Millisecond, Factor: 1}, func() error {
+		calls++
+		return wantErr
+	})
+	if err == nil {
+		t.Fatal("expected error after exhausting attempts")
+	}
+	if !errors.Is(err, wantErr) {
+		t.Errorf("expected wrapped error to contain %v, got %v", wantErr, err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 calls, got %d", calls)
+	}
+	// Повторов на 2 меньше, чем попыток (после последней неудачи больше не спим)
+	if len(delays) != 2 {
+		t.Errorf("expected 2 sleeps, got %d: %v", len(delays), delays)
+	}
+}
+
+func TestDo_BackoffRespectsMaxDelay(t *testing.T) {
+	var delays []time.Duration
+	defer withFakeSleeper(t, &delays, 1)()
+
+	calls := 0
+	_ = Do(context.Background(), Policy{
+		MaxAttempts: 4,
+		BaseDelay:   time.Millisecond,
+		Factor:      10,
+		MaxDelay:    5 * time.Millisecond,
+	}, func() error {
+		calls++
+		return errors.New("fail")
+	})
+
+	want := []time.Duration{time.Millisecond, 5 * time.Millisecond, 5 * time.Millisecond}
+	if len(delays) != len(want) {
+		t.Fatalf("expected %d delays, got %d: %v", len(want), len(delays), delays)
+	}
+	for i, d := range want {
+		if delays[i] != d {
+			t.Errorf("delay %d: expected %v, got %v", i, d, delays[i])
+		}
+	}
+}
+
+func TestDo_CancelDuringBackoff(t *testing.T) {
+	origSleep := sleepFunc
+	defer func() { sleepFunc = origSleep }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sleepFunc = func(ctx context.Context, d time.Duration) error {
+		cancel()
+		return ctx.Err()
+	}
+
+	calls := 0
+	err := Do(ctx, Policy{MaxAttempts: 5, BaseDelay: time.Millisecond}, func() error {
+		calls++
+		return errors.New("fail")
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected exactly 1 call before cancellation, got %d", calls)
+	}
+}
@@ -0,0 +1,342 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// File - минимальный набор операций с открытым файлом, которого достаточно
+// Store для чтения и записи задач.
+type File interface {
+	io.Reader
+	io.Writer
+	io.Closer
+}
+
+// Backend абстрагирует файловые операции, которые использует Store, позволяя
+// хранить задачи не только на локальном диске, но и, например, в памяти (для
+// тестов) или на удаленном HTTP-хранилище.
+//
+// Backend.Open возвращает ошибку, оборачивающую os.ErrNotExist, если path не
+// существует - это проверяется через errors.Is, а не os.IsNotExist, чтобы
+// работало одинаково для всех реализаций, а не только для локальных файлов.
+type Backend interface {
+	Open(path string) (File, error)
+	Create(path string) (File, error)
+	OpenFile(path string, flag int, perm os.FileMode) (File, error)
+	Rename(oldPath, newPath string) error
+	Remove(path string) error
+	Stat(path string) (os.FileInfo, error)
+}
+
+// OSBackend - реализация Backend поверх локальной файловой системы (то, как
+// storage работал исторически, до появления Backend).
+type OSBackend struct{}
+
+func (OSBackend) Open(path string) (File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (OSBackend) Create(path string) (File, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (OSBackend) OpenFile(path string, flag int, perm os.FileMode) (File, error) {
+	f, err := os.OpenFile(path, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (OSBackend) Rename(oldPath, newPath string) error {
+	return os.Rename(oldPath, newPath)
+}
+
+func (OSBackend) Remove(path string) error {
+	return os.Remove(path)
+}
+
+func (OSBackend) Stat(path string) (os.FileInfo, error) {
+	return os.Stat(path)
+}
+
+// memFileInfo - минимальная реализация os.FileInfo для InMemoryBackend.Stat.
+type memFileInfo struct {
+	name string
+	size int64
+}
+
+func (fi memFileInfo) Name() string       { return fi.name }
+func (fi memFileInfo) Size() int64        { return fi.size }
+func (fi memFileInfo) Mode() os.FileMode  { return 0644 }
+func (fi memFileInfo) ModTime() time.Time { return time.Time{} }
+func (fi memFileInfo) IsDir() bool        { return false }
+func (fi memFileInfo) Sys() interface{}   { return nil }
+
+// memFile - File, читающий/пишущий в буфер в памяти InMemoryBackend; при
+// Close() его содержимое сохраняется обратно в backend.
+type memFile struct {
+	backend *InMemoryBackend
+	path    string
+	buf     *bytes.Buffer
+	reader  *bytes.Reader
+	write   bool
+}
+
+func (f *memFile) Read(p []byte) (int, error) {
+	if f.reader == nil {
+		return 0, fmt.Errorf("файл %s открыт только на запись", f.path)
+	}
+	return f.reader.Read(p)
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	if f.buf == nil {
+		return 0, fmt.Errorf("файл %s открыт только на чтение", f.path)
+	}
+	return f.buf.Write(p)
+}
+
+func (f *memFile) Close() error {
+	if f.write {
+		f.backend.set(f.path, f.buf.Bytes())
+	}
+	return nil
+}
+
+// InMemoryBackend - реализация Backend, хранящая "файлы" в памяти процесса;
+// используется в тестах storage-пакета и пакетов, которые на него опираются,
+// чтобы не создавать временные файлы и директории на диске.
+type InMemoryBackend struct {
+	mu    sync.RWMutex
+	files map[string][]byte
+}
+
+// NewInMemoryBackend создает пустой InMemoryBackend.
+func NewInMemoryBackend() *InMemoryBackend {
+	return &InMemoryBackend{files: make(map[string][]byte)}
+}
+
+func (b *InMemoryBackend) get(path string) ([]byte, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	data, ok := b.files[path]
+	return data, ok
+}
+
+func (b *InMemoryBackend) set(path string, data []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	cp := make([]byte, len(data))
+	copy(cp, data)
+	b.files[path] = cp
+}
+
+func (b *InMemoryBackend) Open(path string) (File, error) {
+	data, ok := b.get(path)
+	if !ok {
+		return nil, fmt.Errorf("файл %s не найден: %w", path, os.ErrNotExist)
+	}
+	return &memFile{path: path, reader: bytes.NewReader(data)}, nil
+}
+
+func (b *InMemoryBackend) Create(path string) (File, error) {
+	return &memFile{backend: b, path: path, buf: &bytes.Buffer{}, write: true}, nil
+}
+
+func (b *InMemoryBackend) OpenFile(path string, flag int, _ os.FileMode) (File, error) {
+	if flag&os.O_APPEND != 0 {
+		existing, _ := b.get(path)
+		buf := &bytes.Buffer{}
+		buf.Write(existing)
+		return &memFile{backend: b, path: path, buf: buf, write: true}, nil
+	}
+	return b.Create(path)
+}
+
+func (b *InMemoryBackend) Rename(oldPath, newPath string) error {
+	data, ok := b.get(oldPath)
+	if !ok {
+		return fmt.Errorf("файл %s не найден: %w", oldPath, os.ErrNotExist)
+	}
+	b.set(newPath, data)
+	b.mu.Lock()
+	delete(b.files, oldPath)
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *InMemoryBackend) Remove(path string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.files[path]; !ok {
+		return fmt.Errorf("файл %s не найден: %w", path, os.ErrNotExist)
+	}
+	delete(b.files, path)
+	return nil
+}
+
+func (b *InMemoryBackend) Stat(path string) (os.FileInfo, error) {
+	data, ok := b.get(path)
+	if !ok {
+		return nil, fmt.Errorf("файл %s не найден: %w", path, os.ErrNotExist)
+	}
+	return memFileInfo{name: path, size: int64(len(data))}, nil
+}
+
+// HTTPBackend - реализация Backend поверх удаленного HTTP-хранилища
+// объектов (например, S3-совместимого шлюза): path используется как ключ
+// объекта, который дописывается к BaseURL. GET читает объект, PUT перезаписывает
+// его целиком, DELETE удаляет. У таких хранилищ нет атомарного rename, поэтому
+// Rename реализован как GET старого ключа + PUT под новым + DELETE старого -
+// это НЕ атомарно и может потерять данные при сбое между шагами.
+type HTTPBackend struct {
+	BaseURL string
+	Client  *http.Client
+}
+
+// NewHTTPBackend создает HTTPBackend с указанным базовым URL хранилища.
+func NewHTTPBackend(baseURL string) *HTTPBackend {
+	return &HTTPBackend{BaseURL: baseURL, Client: http.DefaultClient}
+}
+
+func (b *HTTPBackend) client() *http.Client {
+	if b.Client != nil {
+		return b.Client
+	}
+	return http.DefaultClient
+}
+
+func (b *HTTPBackend) url(path string) string {
+	return fmt.Sprintf("%s/%s", b.BaseURL, path)
+}
+
+func (b *HTTPBackend) get(path string) ([]byte, error) {
+	resp, err := b.client().Get(b.url(path))
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить объект %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("объект %s не найден: %w", path, os.ErrNotExist)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("неожиданный статус %d при получении объекта %s", resp.StatusCode, path)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать тело ответа для %s: %w", path, err)
+	}
+	return data, nil
+}
+
+func (b *HTTPBackend) put(path string, data []byte) error {
+	req, err := http.NewRequest(http.MethodPut, b.url(path), bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("не удалось создать PUT-запрос для %s: %w", path, err)
+	}
+	resp, err := b.client().Do(req)
+	if err != nil {
+		return fmt.Errorf("не удалось записать объект %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("неожиданный статус %d при записи объекта %s", resp.StatusCode, path)
+	}
+	return nil
+}
+
+func (b *HTTPBackend) Open(path string) (File, error) {
+	data, err := b.get(path)
+	if err != nil {
+		return nil, err
+	}
+	return &memFile{reader: bytes.NewReader(data)}, nil
+}
+
+// remoteWriteFile записывает в буфер и отправляет его целиком через PUT при Close.
+type remoteWriteFile struct {
+	backend *HTTPBackend
+	path    string
+	buf     *bytes.Buffer
+}
+
+func (f *remoteWriteFile) Write(p []byte) (int, error) {
+	return f.buf.Write(p)
+}
+
+func (f *remoteWriteFile) Read([]byte) (int, error) {
+	return 0, fmt.Errorf("файл %s открыт только на запись", f.path)
+}
+
+func (f *remoteWriteFile) Close() error {
+	return f.backend.put(f.path, f.buf.Bytes())
+}
+
+func (b *HTTPBackend) Create(path string) (File, error) {
+	return &remoteWriteFile{backend: b, path: path, buf: &bytes.Buffer{}}, nil
+}
+
+func (b *HTTPBackend) OpenFile(path string, flag int, _ os.FileMode) (File, error) {
+	buf := &bytes.Buffer{}
+	if flag&os.O_APPEND != 0 {
+		existing, err := b.get(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		buf.Write(existing)
+	}
+	return &remoteWriteFile{backend: b, path: path, buf: buf}, nil
+}
+
+func (b *HTTPBackend) Rename(oldPath, newPath string) error {
+	data, err := b.get(oldPath)
+	if err != nil {
+		return err
+	}
+	if err := b.put(newPath, data); err != nil {
+		return err
+	}
+	return b.Remove(oldPath)
+}
+
+func (b *HTTPBackend) Remove(path string) error {
+	req, err := http.NewRequest(http.MethodDelete, b.url(path), nil)
+	if err != nil {
+		return fmt.Errorf("не удалось создать DELETE-запрос для %s: %w", path, err)
+	}
+	resp, err := b.client().Do(req)
+	if err != nil {
+		return fmt.Errorf("не удалось удалить объект %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("неожиданный статус %d при удалении объекта %s", resp.StatusCode, path)
+	}
+	return nil
+}
+
+func (b *HTTPBackend) Stat(path string) (os.FileInfo, error) {
+	data, err := b.get(path)
+	if err != nil {
+		return nil, err
+	}
+	return memFileInfo{name: path, size: int64(len(data))}, nil
+}
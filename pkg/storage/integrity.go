@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrCorrupt возвращается Store.Load (и, соответственно, LoadTasks), когда
+// контрольная сумма файла задач (футер "#sha256:<hex>" и/или companion-файл
+// ".sum") не совпадает с фактическим содержимым - то есть файл поврежден или
+// обрезан, и доверять ему нельзя.
+var ErrCorrupt = errors.New("storage: файл задач поврежден (контрольная сумма не совпадает)")
+
+const checksumFooterPrefix = "#sha256:"
+
+// checksumOf возвращает hex-представление sha256 от body.
+func checksumOf(body string) string {
+	sum := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(sum[:])
+}
+
+// splitChecksumFooter отделяет от содержимого файла задач завершающий футер
+// "#sha256:<hex>\n" (если он есть). Возвращает тело (строки задач, каждая с
+// исходным "\n" на конце) и hex дайджеста из футера; ok=false означает, что
+// футера нет (старый файл, записанный до появления этой проверки).
+func splitChecksumFooter(content string) (body string, digest string, ok bool) {
+	lines := strings.Split(content, "\n")
+	// Split по "\n" у файла, оканчивающегося на "\n", дает пустой последний
+	// элемент - отбрасываем его, чтобы смотреть на настоящую последнюю строку.
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return content, "", false
+	}
+
+	last := lines[len(lines)-1]
+	if !strings.HasPrefix(last, checksumFooterPrefix) {
+		return content, "", false
+	}
+
+	digest = strings.TrimPrefix(last, checksumFooterPrefix)
+	if len(lines) == 1 {
+		return "", digest, true
+	}
+	return strings.Join(lines[:len(lines)-1], "\n") + "\n", digest, true
+}
+
+// sumSidecarPath возвращает путь companion-файла контрольной суммы для path
+// (tasks.txt -> tasks.txt.sum).
+func sumSidecarPath(path string) string {
+	return path + ".sum"
+}
+
+// writeSumSidecar записывает hex-дайджест digest в companion-файл
+// sumSidecarPath(path).
+func (s *Store) writeSumSidecar(digest string) error {
+	file, err := s.backend.Create(sumSidecarPath(s.path))
+	if err != nil {
+		return fmt.Errorf("не удалось создать файл контрольной суммы %s: %w", sumSidecarPath(s.path), err)
+	}
+	defer file.Close()
+	if _, err := file.Write([]byte(digest + "\n")); err != nil {
+		return fmt.Errorf("не удалось записать файл контрольной суммы %s: %w", sumSidecarPath(s.path), err)
+	}
+	return nil
+}
+
+// readSumSidecar читает hex-дайджест из companion-файла, если он существует.
+// Отсутствие файла не является ошибкой - возвращается ok=false. Любая другая
+// ошибка открытия (права доступа, диск и т.п.) пробрасывается вызывающему,
+// а не тихо трактуется как "файла нет" - иначе Verify/Load могли бы
+// пропустить несовпадение контрольной суммы, которое должны были поймать.
+func (s *Store) readSumSidecar() (digest string, ok bool, err error) {
+	file, openErr := s.backend.Open(sumSidecarPath(s.path))
+	if openErr != nil {
+		if errors.Is(openErr, os.ErrNotExist) {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("не удалось открыть файл контрольной суммы %s: %w", sumSidecarPath(s.path), openErr)
+	}
+	defer file.Close()
+
+	buf := make([]byte, 0, 128)
+	chunk := make([]byte, 128)
+	for {
+		n, readErr := file.Read(chunk)
+		if n > 0 {
+			buf = append(buf, chunk[:n]...)
+		}
+		if readErr != nil {
+			break
+		}
+	}
+	return strings.TrimSpace(string(buf)), true, nil
+}
+
+// Verify проверяет целостность файла задач path: пересчитывает sha256 по
+// содержимому и сверяет его с футером и (если он есть) companion-файлом
+// ".sum". Возвращает ErrCorrupt при несовпадении; старые файлы без футера
+// считаются валидными (проверка пропускается).
+func Verify(path string) error {
+	_, _, err := NewStore(OSBackend{}, path).Load()
+	return err
+}
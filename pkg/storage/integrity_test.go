@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"taskm/pkg/task"
+	"testing"
+)
+
+func TestStore_SaveThenLoad_VerifiesChecksum(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "tasks.txt")
+	store := NewStore(OSBackend{}, filePath)
+
+	tasks := []task.Task{
+		task.AddTask(1, "First", task.PriorityHigh),
+		task.AddTask(2, "Second", task.PriorityLow),
+	}
+	if err := store.Save(tasks); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, _, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load failed on freshly saved file: %v", err)
+	}
+	if len(loaded) != 2 {
+		t.Fatalf("expected 2 tasks, got %d", len(loaded))
+	}
+
+	if err := Verify(filePath); err != nil {
+		t.Errorf("Verify failed on freshly saved file: %v", err)
+	}
+}
+
+func TestStore_Load_DetectsCorruptedBody(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "tasks.txt")
+	store := NewStore(OSBackend{}, filePath)
+
+	if err := store.Save([]task.Task{task.AddTask(1, "Original", task.PriorityMedium)}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	raw, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	tampered := strings.Replace(string(raw), "Original", "Tampered!", 1)
+	if err := os.WriteFile(filePath, []byte(tampered), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	if _, _, err := store.Load(); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("expected ErrCorrupt after tampering with body, got %v", err)
+	}
+	if err := Verify(filePath); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("expected Verify to return ErrCorrupt after tampering with body, got %v", err)
+	}
+}
+
+func TestStore_Load_DetectsSidecarMismatch(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "tasks.txt")
+	store := NewStore(OSBackend{}, filePath)
+
+	if err := store.Save([]task.Task{task.AddTask(1, "Some task", task.PriorityLow)}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	if err := os.WriteFile(sumSidecarPath(filePath), []byte("deadbeef\n"), 0644); err != nil {
+		t.Fatalf("WriteFile sidecar failed: %v", err)
+	}
+
+	if _, _, err := store.Load(); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("expected ErrCorrupt after sidecar mismatch, got %v", err)
+	}
+}
+
+func TestStore_Load_LegacyFileWithoutFooterStillLoads(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "tasks.txt")
+
+	legacyContent := "1|Legacy Task|false|2024-01-01 10:00:00|high\n"
+	if err := os.WriteFile(filePath, []byte(legacyContent), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	tasks, maxID, err := NewStore(OSBackend{}, filePath).Load()
+	if err != nil {
+		t.Fatalf("expected legacy file without checksum footer to load cleanly, got: %v", err)
+	}
+	if len(tasks) != 1 || maxID != 1 {
+		t.Errorf("expected 1 task with maxID 1, got %d tasks, maxID %d", len(tasks), maxID)
+	}
+}
+
+func TestStore_Append_RecomputesFooterAcrossWholeFile(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "tasks.txt")
+	store := NewStore(OSBackend{}, filePath)
+
+	if err := store.Save([]task.Task{task.AddTask(1, "First", task.PriorityHigh)}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := store.Append([]task.Task{task.AddTask(2, "Second", task.PriorityLow)}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	if err := Verify(filePath); err != nil {
+		t.Errorf("expected Append to leave the file with a valid checksum footer, got: %v", err)
+	}
+
+	tasks, _, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load after Append failed: %v", err)
+	}
+	if len(tasks) != 2 {
+		t.Errorf("expected 2 tasks after Append, got %d", len(tasks))
+	}
+}
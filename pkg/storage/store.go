@@ -0,0 +1,405 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"taskm/pkg/events"
+	"taskm/pkg/task"
+	"time"
+)
+
+// Store читает и пишет задачи через Backend, не завязываясь напрямую на
+// локальную файловую систему - это позволяет подменить Backend на
+// InMemoryBackend в тестах или на HTTPBackend для удаленного хранилища.
+type Store struct {
+	backend Backend
+	path    string
+	wal     *WAL     // журнал предзаписи мутаций; nil, пока не включен через EnableWAL
+	watcher *Watcher // отслеживание внешних изменений файла; nil, пока не включено через EnableWatcher
+}
+
+// делаем что-то типа конструктора из ООП для Store
+func NewStore(backend Backend, path string) *Store {
+	return &Store{backend: backend, path: path}
+}
+
+// EnableWAL включает журнал предзаписи (WAL) для этого Store: записи
+// попадают в сегменты в директории dir и fsync-ятся перед возвратом (см.
+// pkg/storage/wal.go). До вызова Checkpoint записи WAL - единственный
+// durable след мутаций между снэпшотами tasks.txt.
+func (s *Store) EnableWAL(dir string, config WALConfig) error {
+	wal, err := NewWAL(dir, config)
+	if err != nil {
+		return err
+	}
+	s.wal = wal
+	return nil
+}
+
+// AppendMutation записывает одну мутацию задачи в WAL, если он включен
+// через EnableWAL; если WAL не включен, это no-op.
+func (s *Store) AppendMutation(op WALOp, t task.Task) error {
+	if s.wal == nil {
+		return nil
+	}
+	_, err := s.wal.AppendTaskMutation(op, t)
+	return err
+}
+
+// AppendDeleteMutation записывает в WAL мутацию удаления задачи id; если
+// WAL не включен, это no-op.
+func (s *Store) AppendDeleteMutation(id int) error {
+	if s.wal == nil {
+		return nil
+	}
+	_, err := s.wal.AppendDelete(id)
+	return err
+}
+
+// Checkpoint атомарно перезаписывает tasks.txt из переданного in-memory
+// состояния (через Save), а затем усекает WAL: записи до чекпоинта больше
+// не нужны для восстановления, т.к. теперь они отражены в снэпшоте.
+func (s *Store) Checkpoint(tasks []task.Task) error {
+	if err := s.Save(tasks); err != nil {
+		return err
+	}
+	if s.wal == nil {
+		return nil
+	}
+	return s.wal.Truncate()
+}
+
+// Close освобождает ресурсы WAL, если он был включен через EnableWAL.
+func (s *Store) Close() error {
+	if s.wal == nil {
+		return nil
+	}
+	return s.wal.Close()
+}
+
+// EnableWatcher включает отслеживание внешних изменений файла хранилища (см.
+// pkg/storage/watcher.go): снимает текущий снимок задач и stat файла как
+// базовую точку отсчета. bus может быть nil, если уведомления через шину
+// событий не нужны - OnReload-колбэки работают в любом случае.
+func (s *Store) EnableWatcher(interval time.Duration, bus *events.Bus) error {
+	watcher, err := NewWatcher(s, interval, bus)
+	if err != nil {
+		return err
+	}
+	s.watcher = watcher
+	return nil
+}
+
+// OnReload регистрирует колбэк, вызываемый при обнаружении внешнего
+// изменения файла хранилища (см. EnableWatcher); если отслеживание не
+// включено, это no-op.
+func (s *Store) OnReload(fn ReloadFunc) {
+	if s.watcher == nil {
+		return
+	}
+	s.watcher.OnReload(fn)
+}
+
+// RunWatcher запускает фоновый опрос файла хранилища на внешние изменения;
+// если отслеживание не включено через EnableWatcher, это no-op.
+func (s *Store) RunWatcher(ctx context.Context, wg *sync.WaitGroup) {
+	if s.watcher == nil {
+		return
+	}
+	s.watcher.Run(ctx, wg)
+}
+
+// Load читает все задачи из хранилища. Если файл (объект) не существует,
+// возвращает пустой список без ошибки - это нормальная ситуация при первом
+// запуске.
+//
+// Если файл заканчивается футером "#sha256:<hex>" (см. pkg/storage/integrity.go),
+// содержимое перед разбором сверяется с этим футером и, если есть, с companion-
+// файлом ".sum" - при несовпадении возвращается ErrCorrupt. Файлы, записанные до
+// появления этой проверки, футера не имеют и загружаются как раньше, без сверки.
+func (s *Store) Load() ([]task.Task, int, error) {
+	file, err := s.backend.Open(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []task.Task{}, 0, nil
+		}
+		return nil, 0, fmt.Errorf("Не удалось открыть файл %s: %w", s.path, err)
+	}
+
+	raw, err := io.ReadAll(file)
+	file.Close()
+	if err != nil {
+		return nil, 0, fmt.Errorf("Ошибка чтения файла %s: %w", s.path, err)
+	}
+
+	body, footerDigest, hasFooter := splitChecksumFooter(string(raw))
+	if hasFooter && checksumOf(body) != footerDigest {
+		return nil, 0, fmt.Errorf("%s: %w", s.path, ErrCorrupt)
+	}
+
+	if sidecarDigest, ok, err := s.readSumSidecar(); err != nil {
+		return nil, 0, err
+	} else if ok && checksumOf(body) != sidecarDigest {
+		return nil, 0, fmt.Errorf("%s: %w (не совпадает с %s)", s.path, ErrCorrupt, sumSidecarPath(s.path))
+	}
+
+	tasks := []task.Task{}
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	maxID := 0
+
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+		newTask, id, ok := parseTaskLine(line, lineNumber, s.path)
+		if !ok {
+			continue
+		}
+
+		tasks = append(tasks, newTask)
+
+		if id > maxID {
+			maxID = id
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, 0, fmt.Errorf("Ошибка чтения файла %s: %w", s.path, err)
+	}
+
+	return tasks, maxID, nil
+}
+
+// Save сохраняет (измененные) задачи в хранилище, атомарно: сначала пишет во
+// временный путь, затем переименовывает его поверх основного. К содержимому
+// дописывается завершающий футер "#sha256:<hex>" (см. integrity.go) с
+// контрольной суммой тела, а рядом записывается companion-файл ".sum" с тем
+// же дайджестом - Load сверяет оба при следующей загрузке.
+func (s *Store) Save(tasks []task.Task) error {
+	var body strings.Builder
+	for _, t := range tasks {
+		body.WriteString(formatTaskLine(t))
+	}
+	digest := checksumOf(body.String())
+
+	tempPath := s.path + ".tmp"
+	file, err := s.backend.Create(tempPath)
+	if err != nil {
+		return fmt.Errorf("Не удалось создать временный файл %s: %w", tempPath, err)
+	}
+
+	writer := bufio.NewWriter(file)
+	if _, err := writer.WriteString(body.String()); err != nil {
+		file.Close()
+		s.backend.Remove(tempPath)
+		return fmt.Errorf("Ошибка записи во временный файл %s: %w", tempPath, err)
+	}
+	if _, err := writer.WriteString(checksumFooterPrefix + digest + "\n"); err != nil {
+		file.Close()
+		s.backend.Remove(tempPath)
+		return fmt.Errorf("Ошибка записи футера контрольной суммы во временный файл %s: %w", tempPath, err)
+	}
+
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		s.backend.Remove(tempPath)
+		return fmt.Errorf("Ошибка записи во временный файл %s: %w", tempPath, err)
+	}
+
+	if err := file.Close(); err != nil {
+		s.backend.Remove(tempPath)
+		return fmt.Errorf("Ошибка закрытия временного файла %s: %w", tempPath, err)
+	}
+
+	if err := s.backend.Rename(tempPath, s.path); err != nil {
+		s.backend.Remove(tempPath)
+		return fmt.Errorf("Ошибка переименования временного файла %s в %s: %w", tempPath, s.path, err)
+	}
+
+	if err := s.writeSumSidecar(digest); err != nil {
+		return err
+	}
+
+	if s.watcher != nil {
+		s.watcher.recordSelfWrite(tasks)
+	}
+	return nil
+}
+
+// Append добавляет задачи в конец хранилища. В отличие от прежней реализации,
+// которая дописывала строки в конец файла напрямую, здесь сначала читается
+// текущее содержимое, к нему добавляются новые задачи, а результат целиком
+// перезаписывается через Save - это нужно, чтобы футер контрольной суммы
+// оставался верным для всего файла, а не только для последнего дописанного
+// куска.
+func (s *Store) Append(tasks []task.Task) error {
+	existing, _, err := s.Load()
+	if err != nil {
+		return fmt.Errorf("не удалось прочитать %s перед добавлением: %w", s.path, err)
+	}
+	return s.Save(append(existing, tasks...))
+}
+
+// parseTaskLine разбирает одну строку файлового формата в task.Task; ok=false
+// означает, что строка невалидна и должна быть пропущена (предупреждение уже
+// выведено в stderr).
+func parseTaskLine(line string, lineNumber int, filePath string) (task.Task, int, bool) {
+	// Разбираем на части: первые пять - ID, Title, Done, CreatedAt, Priority,
+	// остальные - опциональные поля, добавленные позже (CompletedAt, Retention, ...).
+	// Старые строки без этих полей остаются валидными (получают нулевые значения).
+	parts := strings.Split(line, "|")
+	// Проверяем, что у нас как минимум пять частей
+	if len(parts) < 5 {
+		fmt.Fprintf(os.Stderr, "Предупреждение: пропуск неверной строки %d в %s: %s\n", lineNumber, filePath, line)
+		return task.Task{}, 0, false
+	}
+
+	// Преобразуем ID в int
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Предупреждение: пропуск неверной строки %d из-за неверного ID '%s': %v\n", lineNumber, parts[0], err)
+		return task.Task{}, 0, false
+	}
+
+	title := parts[1]
+
+	done, err := strconv.ParseBool(parts[2])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Предупреждение: пропуск неверной строки %d из-за неверного статуса выполнения '%s': %v\n", lineNumber, parts[2], err)
+		return task.Task{}, 0, false
+	}
+
+	created, err := time.Parse(timeFormat, parts[3])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Предупреждение: пропуск неверной строки %d из-за неверной даты '%s': %v\n", lineNumber, parts[3], err)
+		return task.Task{}, 0, false
+	}
+
+	priority := parts[4]
+	// проверяем, правильный ли приоритет
+	switch priority {
+	case task.PriorityHigh, task.PriorityMedium, task.PriorityLow:
+		// в этом случае, все нормальное, ничего не делаем
+	default:
+		// ошибка, если приоритет не 1, 2 или 3
+		fmt.Fprintf(os.Stderr, "Предупреждение: пропуск неверной строки %d из-за неверного приоритета '%s', будет использовано medium\n", lineNumber, priority)
+		priority = task.PriorityMedium
+	}
+
+	newTask := task.Task{
+		ID:        id,
+		Title:     title,
+		Done:      done,
+		CreatedAt: created,
+		Priority:  priority,
+	}
+
+	// Поле CompletedAt (6-е): пусто => нулевое время (старые строки/незавершенные задачи)
+	if len(parts) > 5 && parts[5] != "" {
+		completedAt, err := time.Parse(timeFormat, parts[5])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Предупреждение: не удалось разобрать CompletedAt в строке %d из %s: %v\n", lineNumber, filePath, err)
+		} else {
+			newTask.CompletedAt = completedAt
+		}
+	}
+
+	// Поле Retention (7-е): пусто => 0 (бессрочное хранение)
+	if len(parts) > 6 && parts[6] != "" {
+		retention, err := time.ParseDuration(parts[6])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Предупреждение: не удалось разобрать Retention в строке %d из %s: %v\n", lineNumber, filePath, err)
+		} else {
+			newTask.Retention = retention
+		}
+	}
+
+	// Поле DependsOn (8-е): список ID через запятую, пусто => нет зависимостей
+	if len(parts) > 7 && parts[7] != "" {
+		depStrs := strings.Split(parts[7], ",")
+		dependsOn := make([]int, 0, len(depStrs))
+		for _, depStr := range depStrs {
+			depID, err := strconv.Atoi(depStr)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Предупреждение: не удалось разобрать DependsOn в строке %d из %s: %v\n", lineNumber, filePath, err)
+				continue
+			}
+			dependsOn = append(dependsOn, depID)
+		}
+		newTask.DependsOn = dependsOn
+	}
+
+	// Поле HasNotes (9-е): пусто или отсутствует => false (старые строки)
+	if len(parts) > 8 && parts[8] != "" {
+		hasNotes, err := strconv.ParseBool(parts[8])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Предупреждение: не удалось разобрать HasNotes в строке %d из %s: %v\n", lineNumber, filePath, err)
+		} else {
+			newTask.HasNotes = hasNotes
+		}
+	}
+
+	return newTask, id, true
+}
+
+// Recover восстанавливает состояние задач после возможного сбоя: читает
+// снэпшот snapshotPath (tasks.txt), затем применяет поверх него по порядку
+// seq все записи WAL из walDir. Store.Checkpoint усекает WAL после каждого
+// успешного снэпшота, поэтому на момент восстановления в walDir всегда лежат
+// только мутации, случившиеся после последнего снэпшота.
+func Recover(snapshotPath, walDir string) ([]task.Task, int, error) {
+	tasks, maxID, err := NewStore(OSBackend{}, snapshotPath).Load()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	entries, err := ReplayWAL(walDir)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	byID := make(map[int]task.Task, len(tasks))
+	for _, t := range tasks {
+		byID[t.ID] = t
+	}
+
+	for _, e := range entries {
+		switch e.Op {
+		case WALOpDelete:
+			id, err := strconv.Atoi(e.Payload)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Предупреждение: не удалось разобрать ID удаления в записи WAL seq=%d: %v\n", e.Seq, err)
+				continue
+			}
+			delete(byID, id)
+		case WALOpAdd, WALOpComplete, WALOpEdit:
+			t, id, ok := parseTaskLine(e.Payload, -1, walDir)
+			if !ok {
+				fmt.Fprintf(os.Stderr, "Предупреждение: не удалось разобрать payload записи WAL seq=%d\n", e.Seq)
+				continue
+			}
+			byID[id] = t
+			if id > maxID {
+				maxID = id
+			}
+		default:
+			fmt.Fprintf(os.Stderr, "Предупреждение: неизвестная операция WAL %q в записи seq=%d\n", e.Op, e.Seq)
+		}
+	}
+
+	recovered := make([]task.Task, 0, len(byID))
+	for _, t := range byID {
+		recovered = append(recovered, t)
+	}
+	sort.Slice(recovered, func(i, j int) bool { return recovered[i].ID < recovered[j].ID })
+
+	return recovered, maxID, nil
+}
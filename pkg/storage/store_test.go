@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"taskm/pkg/task"
+	"testing"
+	"time"
+)
+
+func TestInMemoryBackend_SaveLoadCycle(t *testing.T) {
+	backend := NewInMemoryBackend()
+	store := NewStore(backend, "tasks.txt")
+
+	tasks, maxID, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load on empty backend failed: %v", err)
+	}
+	if len(tasks) != 0 || maxID != 0 {
+		t.Errorf("expected empty store, got %d tasks, maxID %d", len(tasks), maxID)
+	}
+
+	time1 := time.Now().Truncate(time.Second)
+	toSave := []task.Task{
+		{ID: 1, Title: "In memory task", Done: false, CreatedAt: time1, Priority: task.PriorityHigh},
+		{ID: 2, Title: "Another task", Done: true, CreatedAt: time1, Priority: task.PriorityLow},
+	}
+	if err := store.Save(toSave); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, maxID, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load after Save failed: %v", err)
+	}
+	if maxID != 2 {
+		t.Errorf("expected maxID 2, got %d", maxID)
+	}
+	if len(loaded) != 2 {
+		t.Fatalf("expected 2 tasks, got %d", len(loaded))
+	}
+}
+
+func TestInMemoryBackend_Append(t *testing.T) {
+	backend := NewInMemoryBackend()
+	store := NewStore(backend, "archive.txt")
+
+	time1 := time.Now().Truncate(time.Second)
+	if err := store.Save([]task.Task{{ID: 1, Title: "First", Done: true, CreatedAt: time1, Priority: task.PriorityMedium}}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := store.Append([]task.Task{{ID: 2, Title: "Second", Done: true, CreatedAt: time1, Priority: task.PriorityLow}}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	loaded, maxID, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if maxID != 2 || len(loaded) != 2 {
+		t.Errorf("expected 2 tasks with maxID 2, got %d tasks maxID %d", len(loaded), maxID)
+	}
+}
+
+func TestInMemoryBackend_Rename(t *testing.T) {
+	backend := NewInMemoryBackend()
+
+	f, err := backend.Create("a.txt")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err := backend.Rename("a.txt", "b.txt"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if _, err := backend.Stat("a.txt"); err == nil {
+		t.Error("expected old path to be gone after rename")
+	}
+	if _, err := backend.Stat("b.txt"); err != nil {
+		t.Errorf("expected new path to exist after rename: %v", err)
+	}
+}
+
+func TestInMemoryBackend_OpenMissingIsNotExist(t *testing.T) {
+	backend := NewInMemoryBackend()
+	if _, err := backend.Open("missing.txt"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected error wrapping os.ErrNotExist opening missing file, got %v", err)
+	}
+}
+
+func TestNewStore_WithOSBackend(t *testing.T) {
+	dir := t.TempDir()
+	filePath := dir + "/tasks.txt"
+	store := NewStore(OSBackend{}, filePath)
+
+	if err := store.Save([]task.Task{task.AddTask(1, "OS backed task", task.PriorityHigh)}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	loaded, _, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(loaded) != 1 || loaded[0].Title != "OS backed task" {
+		t.Errorf("unexpected loaded tasks: %+v", loaded)
+	}
+}
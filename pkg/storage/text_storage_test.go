@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"taskm/pkg/task"
 	"testing"
 	"time"
@@ -171,14 +172,24 @@ func TestSaveTasks(t *testing.T) {
 	content := string(contentBytes)
 
 	timeFormat := "2006-01-02 15:04:05"
-	expectedContent := fmt.Sprintf("1|Save Me|false|%s|medium\n"+
-									"20|Save Me Too|true|%s|high\n",
-									time1.Format(timeFormat), time2.Format(timeFormat))
+	expectedBody := fmt.Sprintf("1|Save Me|false|%s|medium||0s||false\n"+
+								"20|Save Me Too|true|%s|high||0s||false\n",
+								time1.Format(timeFormat), time2.Format(timeFormat))
+	expectedContent := expectedBody + checksumFooterPrefix + checksumOf(expectedBody) + "\n"
 
 	if content != expectedContent {
 		t.Errorf("File content mismatch.\nExpected:\n%s\nActual:\n%s", expectedContent, content)
 	}
 
+	// Companion-файл контрольной суммы должен содержать тот же дайджест.
+	sumBytes, err := os.ReadFile(filePath + ".sum")
+	if err != nil {
+		t.Fatalf("Failed to read .sum sidecar: %v", err)
+	}
+	if strings.TrimSpace(string(sumBytes)) != checksumOf(expectedBody) {
+		t.Errorf("Sidecar checksum mismatch.\nExpected: %s\nActual:   %s", checksumOf(expectedBody), string(sumBytes))
+	}
+
 	// Дополнительно: загружаем и проверяем
 	loadedTasks, maxID, err := LoadTasks(filePath)
 	if err != nil {
@@ -226,13 +237,15 @@ func TestAppendTask(t *testing.T) {
 	}
 	content := string(contentBytes)
 
-	// Ожидаемое содержимое: начальная задача + добавленные
-	expectedContent := fmt.Sprintf("1|Initial Task|false|%s|low\n"+ // Из SaveTasks
-								   "5|Appended Task 1|true|%s|high\n"+ // Из AppendTask
-								   "3|Appended Task 2|false|%s|medium\n", // Из AppendTask
+	// Ожидаемое содержимое: начальная задача + добавленные, плюс футер
+	// контрольной суммы, пересчитанный AppendTask по всему файлу.
+	expectedBody := fmt.Sprintf("1|Initial Task|false|%s|low||0s||false\n"+ // Из SaveTasks
+								   "5|Appended Task 1|true|%s|high||0s||false\n"+ // Из AppendTask
+								   "3|Appended Task 2|false|%s|medium||0s||false\n", // Из AppendTask
 									time1.Format(timeFormat),
 									time2.Format(timeFormat),
 									time3.Format(timeFormat))
+	expectedContent := expectedBody + checksumFooterPrefix + checksumOf(expectedBody) + "\n"
 
 	if content != expectedContent {
 		t.Errorf("File content mismatch after AppendTask.\nExpected:\n%s\nActual:\n%s", expectedContent, content)
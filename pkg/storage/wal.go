@@ -0,0 +1,492 @@
+package storage
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"taskm/pkg/task"
+	"time"
+)
+
+// WALOp - тип мутации, записываемой в WAL (см. WAL.Append).
+type WALOp string
+
+const (
+	WALOpAdd      WALOp = "add"
+	WALOpComplete WALOp = "complete"
+	WALOpDelete   WALOp = "delete"
+	WALOpEdit     WALOp = "edit"
+)
+
+// WALEntry - одна разобранная запись WAL.
+type WALEntry struct {
+	Seq     uint64
+	Op      WALOp
+	Payload string
+}
+
+// WALConfig настраивает размер сегмента WAL и глубину пайплайна
+// преаллоцированных сегментов (см. segmentPipeline).
+type WALConfig struct {
+	SegmentSize   int64 // размер сегмента в байтах, под который он преаллоцируется
+	PipelineDepth int   // сколько готовых сегментов держать в запасе
+}
+
+func (c WALConfig) withDefaults() WALConfig {
+	if c.SegmentSize <= 0 {
+		c.SegmentSize = 4 * 1024 * 1024 // 4 МиБ
+	}
+	if c.PipelineDepth <= 0 {
+		c.PipelineDepth = 1
+	}
+	return c
+}
+
+// WAL - журнал предзаписи (write-ahead log) для мутаций задач: каждая
+// мутация (add/complete/delete/edit) дописывается в текущий активный сегмент
+// как запись "seq|op|payload\n" и fsync-ится перед возвратом вызывающему
+// коду. Когда активный сегмент заполняется, WAL переключается на уже готовый
+// преаллоцированный сегмент из segmentPipeline, чтобы переключение не
+// стопорилось на создании и Truncate() нового файла на диске.
+//
+// WAL - опциональная (включаемая через Store.EnableWAL) надстройка над
+// Store: Store.Save/Load продолжают работать как раньше (снэпшот tasks.txt),
+// а WAL дает durability мутаций между снэпшотами.
+type WAL struct {
+	dir      string
+	config   WALConfig
+	pipeline *segmentPipeline
+
+	mu           sync.Mutex
+	active       *os.File
+	activePath   string
+	written      int64
+	segments     []string // пути уже закрытых (прошлых) сегментов, по порядку
+	segmentIndex int      // номер, который получит следующий финализированный сегмент
+	nextSeq      uint64
+}
+
+// NewWAL открывает (или создает) WAL в директории dir. Если в dir уже есть
+// сегменты tasks-*.wal, последний из них становится активным и дозаписывается,
+// а nextSeq выставляется на основе последней разобранной записи, чтобы seq
+// не повторялись после перезапуска.
+func NewWAL(dir string, config WALConfig) (*WAL, error) {
+	config = config.withDefaults()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("не удалось создать директорию WAL %s: %w", dir, err)
+	}
+
+	// Незавершенные преаллоцированные файлы из прошлого запуска (которые не
+	// успели стать активным сегментом до падения процесса) не нужны -
+	// новый пайплайн подготовит свои.
+	if err := removeStalePendingSegments(dir); err != nil {
+		return nil, err
+	}
+
+	existing, err := walSegmentPaths(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &WAL{
+		dir:          dir,
+		config:       config,
+		segmentIndex: nextSegmentIndex(existing),
+		pipeline:     newSegmentPipeline(dir, config.SegmentSize, config.PipelineDepth),
+	}
+
+	entries, err := ReplayWAL(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.Seq >= w.nextSeq {
+			w.nextSeq = e.Seq + 1
+		}
+	}
+
+	if len(existing) == 0 {
+		if err := w.rollToNewSegment(); err != nil {
+			return nil, err
+		}
+		return w, nil
+	}
+
+	w.segments = existing[:len(existing)-1]
+	lastPath := existing[len(existing)-1]
+	f, err := os.OpenFile(lastPath, os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть активный сегмент WAL %s: %w", lastPath, err)
+	}
+	offset, err := walWrittenOffset(lastPath)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	w.active = f
+	w.activePath = lastPath
+	w.written = offset
+	return w, nil
+}
+
+// Append кодирует мутацию как запись "seq|op|payload\n", дописывает ее в
+// активный сегмент и fsync-ит файл перед возвратом, чтобы вызывающий код мог
+// полагаться на durability записи. Возвращает seq записи.
+func (w *WAL) Append(op WALOp, payload string) (uint64, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	seq := w.nextSeq
+	w.nextSeq++
+
+	line := fmt.Sprintf("%d|%s|%s\n", seq, op, payload)
+	if _, err := w.active.WriteAt([]byte(line), w.written); err != nil {
+		return 0, fmt.Errorf("не удалось записать в WAL: %w", err)
+	}
+	w.written += int64(len(line))
+
+	if err := w.active.Sync(); err != nil {
+		return 0, fmt.Errorf("не удалось fsync-ить WAL: %w", err)
+	}
+
+	if w.written >= w.config.SegmentSize {
+		if err := w.rollToNewSegment(); err != nil {
+			return seq, fmt.Errorf("запись %d сохранена, но не удалось переключить сегмент WAL: %w", seq, err)
+		}
+	}
+
+	return seq, nil
+}
+
+// AppendTaskMutation - удобный помощник поверх Append: кодирует полное
+// состояние задачи (тем же форматом, что и formatTaskLine) как payload для
+// add/complete/edit.
+func (w *WAL) AppendTaskMutation(op WALOp, t task.Task) (uint64, error) {
+	payload := strings.TrimSuffix(formatTaskLine(t), "\n")
+	return w.Append(op, payload)
+}
+
+// AppendDelete - удобный помощник поверх Append для мутации удаления, где
+// payload - это просто ID удаленной задачи.
+func (w *WAL) AppendDelete(id int) (uint64, error) {
+	return w.Append(WALOpDelete, strconv.Itoa(id))
+}
+
+// rollToNewSegment закрывает текущий активный сегмент (если есть) и
+// переключается на уже готовый преаллоцированный сегмент из пайплайна.
+// Вызывающий код должен держать w.mu.
+func (w *WAL) rollToNewSegment() error {
+	if w.active != nil {
+		w.segments = append(w.segments, w.activePath)
+		if err := w.active.Close(); err != nil {
+			return fmt.Errorf("не удалось закрыть сегмент WAL %s: %w", w.activePath, err)
+		}
+	}
+
+	f, pendingPath, err := w.pipeline.next()
+	if err != nil {
+		return err
+	}
+
+	finalPath := filepath.Join(w.dir, fmt.Sprintf("tasks-%06d.wal", w.segmentIndex))
+	w.segmentIndex++
+	if err := os.Rename(pendingPath, finalPath); err != nil {
+		f.Close()
+		return fmt.Errorf("не удалось переименовать сегмент WAL %s в %s: %w", pendingPath, finalPath, err)
+	}
+
+	w.active = f
+	w.activePath = finalPath
+	w.written = 0
+	return nil
+}
+
+// Truncate сбрасывает WAL в пустое состояние: закрывает и удаляет все
+// сегменты (включая активный), после чего WAL снова готов писать с seq=0.
+// Вызывается из Store.Checkpoint после того, как снэпшот tasks.txt успешно
+// записан - записи WAL до чекпоинта больше не нужны для восстановления.
+func (w *WAL) Truncate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.active != nil {
+		w.active.Close()
+		os.Remove(w.activePath)
+		w.active = nil
+	}
+	for _, path := range w.segments {
+		os.Remove(path)
+	}
+	w.segments = nil
+	w.written = 0
+	w.nextSeq = 0
+	w.segmentIndex = 0
+
+	return w.rollToNewSegment()
+}
+
+// Close закрывает активный сегмент и останавливает пайплайн преаллокации.
+func (w *WAL) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.pipeline.close()
+	if w.active != nil {
+		return w.active.Close()
+	}
+	return nil
+}
+
+// ReplayWAL читает все сегменты WAL в директории dir по порядку и
+// возвращает разобранные записи, отсортированные по seq. Используется при
+// восстановлении после сбоя: Recover применяет поверх снэпшота tasks.txt все
+// возвращенные записи; снэпшот не хранит seq, поэтому записи по seq не
+// отфильтровываются.
+func ReplayWAL(dir string) ([]WALEntry, error) {
+	paths, err := walSegmentPaths(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []WALEntry
+	for _, path := range paths {
+		segEntries, err := readWALSegment(path)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, segEntries...)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Seq < entries[j].Seq })
+	return entries, nil
+}
+
+// walWrittenOffset определяет, сколько байт сегмента path реально занято
+// валидными записями (в отличие от преаллоцированного, но еще не
+// записанного нулевого хвоста).
+func walWrittenOffset(path string) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось открыть сегмент WAL %s: %w", path, err)
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	var offset int64
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			break
+		}
+		if isAllZero([]byte(line)) {
+			break
+		}
+		offset += int64(len(line))
+	}
+	return offset, nil
+}
+
+// readWALSegment разбирает один файл сегмента на записи; преаллоцированный,
+// но еще не записанный нулевой хвост сегмента молча игнорируется - это не
+// ошибка, а нормальный конец записанных данных.
+func readWALSegment(path string) ([]WALEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть сегмент WAL %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var entries []WALEntry
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if len(line) > 0 && !isAllZero([]byte(line)) {
+				fmt.Fprintf(os.Stderr, "Предупреждение: обрезанная запись WAL в %s пропущена\n", path)
+			}
+			break
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if isAllZero([]byte(line)) {
+			break
+		}
+
+		parts := strings.SplitN(line, "|", 3)
+		if len(parts) < 2 {
+			fmt.Fprintf(os.Stderr, "Предупреждение: пропуск неверной записи WAL в %s: %s\n", path, line)
+			continue
+		}
+		seq, err := strconv.ParseUint(parts[0], 10, 64)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Предупреждение: пропуск записи WAL с неверным seq в %s: %s\n", path, line)
+			continue
+		}
+		payload := ""
+		if len(parts) > 2 {
+			payload = parts[2]
+		}
+		entries = append(entries, WALEntry{Seq: seq, Op: WALOp(parts[1]), Payload: payload})
+	}
+	return entries, nil
+}
+
+func isAllZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// walSegmentPaths возвращает пути финализированных сегментов WAL в dir,
+// отсортированные по возрастанию номера сегмента (сортировка строк работает
+// благодаря фиксированной ширине номера в имени файла, см. prepareSegment).
+func walSegmentPaths(dir string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "tasks-*.wal"))
+	if err != nil {
+		return nil, fmt.Errorf("не удалось перечислить сегменты WAL в %s: %w", dir, err)
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// nextSegmentIndex вычисляет номер, который получит следующий
+// финализированный сегмент, на основе уже существующих файлов tasks-*.wal.
+func nextSegmentIndex(existing []string) int {
+	max := -1
+	for _, path := range existing {
+		name := filepath.Base(path)
+		name = strings.TrimPrefix(name, "tasks-")
+		name = strings.TrimSuffix(name, ".wal")
+		n, err := strconv.Atoi(name)
+		if err != nil {
+			continue
+		}
+		if n > max {
+			max = n
+		}
+	}
+	return max + 1
+}
+
+// removeStalePendingSegments удаляет преаллоцированные, но не
+// востребованные файлы сегментов, оставшиеся от пайплайна предыдущего
+// запуска (см. segmentPipeline.prepareSegment).
+func removeStalePendingSegments(dir string) error {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.wal.pending"))
+	if err != nil {
+		return fmt.Errorf("не удалось перечислить незавершенные сегменты WAL в %s: %w", dir, err)
+	}
+	for _, path := range matches {
+		os.Remove(path)
+	}
+	return nil
+}
+
+// segmentPipeline держит небольшой запас уже созданных и преаллоцированных
+// (Truncate()) сегментов WAL готовыми к использованию, чтобы переключение
+// активного сегмента (WAL.rollToNewSegment) не ждало создания файла и
+// выделения места под него на диске - это единственная цель фоновой
+// горутины run(). Подготовленные файлы получают временное имя с суффиксом
+// .wal.pending и переименовываются в финальное "tasks-NNNNNN.wal" только в
+// момент, когда WAL реально начинает в них писать.
+type segmentPipeline struct {
+	dir         string
+	segmentSize int64
+
+	mu      sync.Mutex
+	seq     int64
+	done    chan struct{}
+	stopped chan struct{} // закрывается в конце run(), см. close()
+	out     chan pendingSegment
+}
+
+type pendingSegment struct {
+	file *os.File
+	path string
+}
+
+func newSegmentPipeline(dir string, segmentSize int64, depth int) *segmentPipeline {
+	p := &segmentPipeline{
+		dir:         dir,
+		segmentSize: segmentSize,
+		done:        make(chan struct{}),
+		stopped:     make(chan struct{}),
+		out:         make(chan pendingSegment, depth),
+	}
+	go p.run()
+	return p
+}
+
+func (p *segmentPipeline) run() {
+	defer close(p.stopped)
+	for {
+		seg, err := p.prepareSegment()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "WAL: не удалось подготовить сегмент: %v\n", err)
+			select {
+			case <-time.After(time.Second):
+				continue
+			case <-p.done:
+				return
+			}
+		}
+
+		select {
+		case p.out <- seg:
+		case <-p.done:
+			seg.file.Close()
+			os.Remove(seg.path)
+			return
+		}
+	}
+}
+
+func (p *segmentPipeline) prepareSegment() (pendingSegment, error) {
+	p.mu.Lock()
+	id := p.seq
+	p.seq++
+	p.mu.Unlock()
+
+	path := filepath.Join(p.dir, fmt.Sprintf("pending-%d.wal.pending", id))
+	f, err := os.Create(path)
+	if err != nil {
+		return pendingSegment{}, fmt.Errorf("не удалось создать сегмент WAL %s: %w", path, err)
+	}
+	if err := f.Truncate(p.segmentSize); err != nil {
+		f.Close()
+		os.Remove(path)
+		return pendingSegment{}, fmt.Errorf("не удалось преаллоцировать сегмент WAL %s: %w", path, err)
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		f.Close()
+		os.Remove(path)
+		return pendingSegment{}, fmt.Errorf("не удалось перемотать сегмент WAL %s: %w", path, err)
+	}
+	return pendingSegment{file: f, path: path}, nil
+}
+
+// next возвращает следующий готовый сегмент (и его путь до переименования в
+// финальное имя), блокируясь, если пайплайн еще не успел его подготовить.
+func (p *segmentPipeline) next() (*os.File, string, error) {
+	seg, ok := <-p.out
+	if !ok {
+		return nil, "", fmt.Errorf("пайплайн сегментов WAL закрыт")
+	}
+	return seg.file, seg.path, nil
+}
+
+// close останавливает пайплайн и блокируется, пока run() не завершится -
+// иначе вызывающий код (например, удаляющий временную директорию после
+// Close()) мог бы гоняться с фоновой горутиной, еще дописывающей
+// .wal.pending файл.
+func (p *segmentPipeline) close() {
+	close(p.done)
+	<-p.stopped
+}
@@ -0,0 +1,168 @@
+package storage
+
+import (
+	"path/filepath"
+	"taskm/pkg/task"
+	"testing"
+	"time"
+)
+
+func TestWAL_AppendAndReplay(t *testing.T) {
+	dir := t.TempDir()
+	wal, err := NewWAL(dir, WALConfig{})
+	if err != nil {
+		t.Fatalf("NewWAL failed: %v", err)
+	}
+	defer wal.Close()
+
+	now := time.Now().Truncate(time.Second)
+	t1 := task.Task{ID: 1, Title: "First", Done: false, CreatedAt: now, Priority: task.PriorityHigh}
+	t2 := task.Task{ID: 2, Title: "Second", Done: false, CreatedAt: now, Priority: task.PriorityLow}
+
+	if _, err := wal.AppendTaskMutation(WALOpAdd, t1); err != nil {
+		t.Fatalf("AppendTaskMutation(t1) failed: %v", err)
+	}
+	if _, err := wal.AppendTaskMutation(WALOpAdd, t2); err != nil {
+		t.Fatalf("AppendTaskMutation(t2) failed: %v", err)
+	}
+	if _, err := wal.AppendDelete(1); err != nil {
+		t.Fatalf("AppendDelete failed: %v", err)
+	}
+
+	entries, err := ReplayWAL(dir)
+	if err != nil {
+		t.Fatalf("ReplayWAL failed: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 WAL entries, got %d", len(entries))
+	}
+	if entries[0].Op != WALOpAdd || entries[2].Op != WALOpDelete {
+		t.Errorf("unexpected entry ops: %+v", entries)
+	}
+	if entries[0].Seq != 0 || entries[1].Seq != 1 || entries[2].Seq != 2 {
+		t.Errorf("expected sequential seq 0,1,2, got %d,%d,%d", entries[0].Seq, entries[1].Seq, entries[2].Seq)
+	}
+}
+
+func TestWAL_SegmentRollover(t *testing.T) {
+	dir := t.TempDir()
+	// Маленький сегмент, чтобы несколько записей гарантированно вызвали ротацию.
+	wal, err := NewWAL(dir, WALConfig{SegmentSize: 64, PipelineDepth: 2})
+	if err != nil {
+		t.Fatalf("NewWAL failed: %v", err)
+	}
+	defer wal.Close()
+
+	now := time.Now().Truncate(time.Second)
+	for i := 0; i < 10; i++ {
+		tk := task.Task{ID: i, Title: "Task", Done: false, CreatedAt: now, Priority: task.PriorityMedium}
+		if _, err := wal.AppendTaskMutation(WALOpAdd, tk); err != nil {
+			t.Fatalf("AppendTaskMutation(%d) failed: %v", i, err)
+		}
+	}
+
+	segments, err := walSegmentPaths(dir)
+	if err != nil {
+		t.Fatalf("walSegmentPaths failed: %v", err)
+	}
+	if len(segments) < 2 {
+		t.Fatalf("expected rollover to produce multiple segments, got %d", len(segments))
+	}
+
+	entries, err := ReplayWAL(dir)
+	if err != nil {
+		t.Fatalf("ReplayWAL failed: %v", err)
+	}
+	if len(entries) != 10 {
+		t.Fatalf("expected 10 entries across segments, got %d", len(entries))
+	}
+	for i, e := range entries {
+		if e.Seq != uint64(i) {
+			t.Errorf("expected entries in seq order, entry %d has seq %d", i, e.Seq)
+		}
+	}
+}
+
+func TestStore_CheckpointTruncatesWAL(t *testing.T) {
+	dir := t.TempDir()
+	snapshotPath := filepath.Join(dir, "tasks.txt")
+	walDir := filepath.Join(dir, "wal")
+
+	store := NewStore(OSBackend{}, snapshotPath)
+	if err := store.EnableWAL(walDir, WALConfig{}); err != nil {
+		t.Fatalf("EnableWAL failed: %v", err)
+	}
+	defer store.Close()
+
+	now := time.Now().Truncate(time.Second)
+	t1 := task.Task{ID: 1, Title: "Checkpointed", Done: false, CreatedAt: now, Priority: task.PriorityHigh}
+	if err := store.AppendMutation(WALOpAdd, t1); err != nil {
+		t.Fatalf("AppendMutation failed: %v", err)
+	}
+
+	if err := store.Checkpoint([]task.Task{t1}); err != nil {
+		t.Fatalf("Checkpoint failed: %v", err)
+	}
+
+	entries, err := ReplayWAL(walDir)
+	if err != nil {
+		t.Fatalf("ReplayWAL failed: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected WAL to be empty after checkpoint, got %d entries", len(entries))
+	}
+
+	loaded, _, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(loaded) != 1 || loaded[0].ID != 1 {
+		t.Errorf("expected checkpointed snapshot to contain task 1, got %+v", loaded)
+	}
+}
+
+func TestRecover_AppliesWALOnTopOfSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	snapshotPath := filepath.Join(dir, "tasks.txt")
+	walDir := filepath.Join(dir, "wal")
+
+	now := time.Now().Truncate(time.Second)
+	snapshotTasks := []task.Task{
+		{ID: 1, Title: "From snapshot", Done: false, CreatedAt: now, Priority: task.PriorityLow},
+		{ID: 2, Title: "Will be deleted", Done: false, CreatedAt: now, Priority: task.PriorityLow},
+	}
+	if err := NewStore(OSBackend{}, snapshotPath).Save(snapshotTasks); err != nil {
+		t.Fatalf("Save snapshot failed: %v", err)
+	}
+
+	wal, err := NewWAL(walDir, WALConfig{})
+	if err != nil {
+		t.Fatalf("NewWAL failed: %v", err)
+	}
+	newTask := task.Task{ID: 3, Title: "From WAL", Done: false, CreatedAt: now, Priority: task.PriorityHigh}
+	if _, err := wal.AppendTaskMutation(WALOpAdd, newTask); err != nil {
+		t.Fatalf("AppendTaskMutation failed: %v", err)
+	}
+	if _, err := wal.AppendDelete(2); err != nil {
+		t.Fatalf("AppendDelete failed: %v", err)
+	}
+	wal.Close()
+
+	recovered, maxID, err := Recover(snapshotPath, walDir)
+	if err != nil {
+		t.Fatalf("Recover failed: %v", err)
+	}
+	if maxID != 3 {
+		t.Errorf("expected maxID 3, got %d", maxID)
+	}
+	if len(recovered) != 2 {
+		t.Fatalf("expected 2 tasks after recovery (1 and 3), got %d: %+v", len(recovered), recovered)
+	}
+	ids := map[int]bool{}
+	for _, rt := range recovered {
+		ids[rt.ID] = true
+	}
+	if !ids[1] || !ids[3] || ids[2] {
+		t.Errorf("expected tasks {1,3} present and 2 deleted, got %+v", recovered)
+	}
+}
@@ -0,0 +1,199 @@
+package storage
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"taskm/pkg/events"
+	"taskm/pkg/task"
+	"time"
+)
+
+// ReloadFunc - колбэк, регистрируемый через Store.OnReload/Watcher.OnReload,
+// вызываемый при обнаружении внешнего изменения файла хранилища. added,
+// removed и changed - задачи, которых не было/больше нет/изменились
+// относительно последнего известного in-memory снимка.
+type ReloadFunc func(added, removed, changed []task.Task)
+
+// Watcher периодически проверяет файл хранилища на предмет внешних
+// изменений (через Backend.Stat - mtime и размер, как простая проверка
+// "устарел ли наш снимок") и, если он изменился с момента последнего
+// известного состояния, перечитывает его через Store.Load, сравнивает с
+// предыдущим снимком и уведомляет подписчиков через зарегистрированные
+// ReloadFunc и (если задан) events.Bus.
+//
+// В этом дереве нет go.mod и сторонних зависимостей, поэтому опрос через
+// Stat - единственный вариант без изобретения поддельного vendoring для
+// fsnotify; внешний API Watcher не завязан на способ обнаружения изменений,
+// так что опрос можно будет заменить на fsnotify позже, не меняя вызывающий
+// код.
+type Watcher struct {
+	store    *Store
+	interval time.Duration
+	bus      *events.Bus
+
+	mu        sync.Mutex
+	lastMod   time.Time
+	lastSize  int64
+	snapshot  map[int]task.Task
+	callbacks []ReloadFunc
+}
+
+// NewWatcher снимает текущее состояние store (список задач и stat файла) как
+// базовую точку отсчета и возвращает Watcher, готовый к запуску через Run.
+func NewWatcher(store *Store, interval time.Duration, bus *events.Bus) (*Watcher, error) {
+	tasks, _, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := make(map[int]task.Task, len(tasks))
+	for _, t := range tasks {
+		snapshot[t.ID] = t
+	}
+
+	var lastMod time.Time
+	var lastSize int64
+	if stat, statErr := store.backend.Stat(store.path); statErr == nil {
+		lastMod = stat.ModTime()
+		lastSize = stat.Size()
+	}
+
+	return &Watcher{
+		store:    store,
+		interval: interval,
+		bus:      bus,
+		lastMod:  lastMod,
+		lastSize: lastSize,
+		snapshot: snapshot,
+	}, nil
+}
+
+// OnReload регистрирует колбэк, вызываемый при обнаружении внешнего
+// изменения файла хранилища.
+func (w *Watcher) OnReload(fn ReloadFunc) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.callbacks = append(w.callbacks, fn)
+}
+
+// Run запускает фоновую горутину, которая опрашивает файл хранилища каждые
+// w.interval и останавливается при отмене ctx.
+func (w *Watcher) Run(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				w.poll()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// recordSelfWrite обновляет известное состояние Watcher сразу после
+// успешной записи этим же процессом (вызывается из Store.Save), чтобы
+// следующий poll не принял собственную запись за внешнее изменение.
+func (w *Watcher) recordSelfWrite(tasks []task.Task) {
+	stat, err := w.store.backend.Stat(w.store.path)
+	if err != nil {
+		return
+	}
+
+	snapshot := make(map[int]task.Task, len(tasks))
+	for _, t := range tasks {
+		snapshot[t.ID] = t
+	}
+
+	w.mu.Lock()
+	w.lastMod = stat.ModTime()
+	w.lastSize = stat.Size()
+	w.snapshot = snapshot
+	w.mu.Unlock()
+}
+
+// poll сверяет stat файла с последним известным, и при расхождении
+// перечитывает файл, вычисляет разницу со старым снимком и уведомляет
+// подписчиков.
+func (w *Watcher) poll() {
+	stat, err := w.store.backend.Stat(w.store.path)
+	if err != nil {
+		return
+	}
+
+	w.mu.Lock()
+	unchanged := stat.ModTime().Equal(w.lastMod) && stat.Size() == w.lastSize
+	w.mu.Unlock()
+	if unchanged {
+		return
+	}
+
+	tasks, _, err := w.store.Load()
+	if err != nil {
+		if w.bus != nil {
+			w.bus.Publish(events.NewEvent(events.KindStoreCorrupted, err))
+		}
+		return
+	}
+
+	newSnapshot := make(map[int]task.Task, len(tasks))
+	for _, t := range tasks {
+		newSnapshot[t.ID] = t
+	}
+
+	w.mu.Lock()
+	oldSnapshot := w.snapshot
+	callbacks := append([]ReloadFunc(nil), w.callbacks...)
+	w.mu.Unlock()
+
+	var added, removed, changed []task.Task
+	for id, t := range newSnapshot {
+		old, ok := oldSnapshot[id]
+		if !ok {
+			added = append(added, t)
+		} else if !reflect.DeepEqual(old, t) {
+			changed = append(changed, t)
+		}
+	}
+	for id, t := range oldSnapshot {
+		if _, ok := newSnapshot[id]; !ok {
+			removed = append(removed, t)
+		}
+	}
+
+	w.mu.Lock()
+	w.snapshot = newSnapshot
+	w.lastMod = stat.ModTime()
+	w.lastSize = stat.Size()
+	w.mu.Unlock()
+
+	if len(added) == 0 && len(removed) == 0 && len(changed) == 0 {
+		return
+	}
+
+	for _, cb := range callbacks {
+		cb(added, removed, changed)
+	}
+
+	if w.bus == nil {
+		return
+	}
+	for _, t := range added {
+		w.bus.Publish(events.NewEvent(events.KindTaskAdded, t))
+	}
+	for _, t := range removed {
+		// Payload - int ID, а не task.Task: main.go's handleDeleteTask
+		// публикует KindTaskDeleted с тем же ID-only payload'ом, и подписчики
+		// не должны гадать, какой из двух типов прилетит для одного Kind.
+		w.bus.Publish(events.NewEvent(events.KindTaskDeleted, t.ID))
+	}
+	for _, t := range changed {
+		w.bus.Publish(events.NewEvent(events.KindTaskEdited, t))
+	}
+}
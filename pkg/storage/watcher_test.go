@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"taskm/pkg/events"
+	"taskm/pkg/task"
+	"testing"
+	"time"
+)
+
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("timed out waiting for condition")
+}
+
+func TestWatcher_DetectsExternalChange(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "tasks.txt")
+	store := NewStore(OSBackend{}, filePath)
+
+	if err := store.Save([]task.Task{task.AddTask(1, "Original", task.PriorityMedium)}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := store.EnableWatcher(10*time.Millisecond, nil); err != nil {
+		t.Fatalf("EnableWatcher failed: %v", err)
+	}
+
+	var mu sync.Mutex
+	var gotAdded, gotRemoved, gotChanged []task.Task
+	store.OnReload(func(added, removed, changed []task.Task) {
+		mu.Lock()
+		defer mu.Unlock()
+		gotAdded = append(gotAdded, added...)
+		gotRemoved = append(gotRemoved, removed...)
+		gotChanged = append(gotChanged, changed...)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var wg sync.WaitGroup
+	store.RunWatcher(ctx, &wg)
+
+	// Имитируем внешнюю запись другим процессом: отдельный Store на тот же
+	// файл, без watcher.
+	external := NewStore(OSBackend{}, filePath)
+	time.Sleep(10 * time.Millisecond) // гарантируем отличный mtime на грубых ФС
+	if err := external.Save([]task.Task{task.AddTask(2, "From outside", task.PriorityHigh)}); err != nil {
+		t.Fatalf("external Save failed: %v", err)
+	}
+
+	waitFor(t, 2*time.Second, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(gotAdded) > 0 || len(gotRemoved) > 0
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	foundAdded, foundRemoved := false, false
+	for _, tk := range gotAdded {
+		if tk.ID == 2 {
+			foundAdded = true
+		}
+	}
+	for _, tk := range gotRemoved {
+		if tk.ID == 1 {
+			foundRemoved = true
+		}
+	}
+	if !foundAdded {
+		t.Errorf("expected task 2 to be reported as added, got added=%+v", gotAdded)
+	}
+	if !foundRemoved {
+		t.Errorf("expected task 1 to be reported as removed, got removed=%+v", gotRemoved)
+	}
+}
+
+func TestWatcher_SelfWriteDoesNotTriggerReload(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "tasks.txt")
+	store := NewStore(OSBackend{}, filePath)
+
+	if err := store.Save([]task.Task{task.AddTask(1, "Original", task.PriorityMedium)}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := store.EnableWatcher(10*time.Millisecond, nil); err != nil {
+		t.Fatalf("EnableWatcher failed: %v", err)
+	}
+
+	reloadCount := 0
+	var mu sync.Mutex
+	store.OnReload(func(added, removed, changed []task.Task) {
+		mu.Lock()
+		defer mu.Unlock()
+		reloadCount++
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	store.RunWatcher(ctx, &wg)
+
+	// Запись через тот же Store должна считаться "своей" и не должна
+	// вызывать reload.
+	if err := store.Save([]task.Task{
+		task.AddTask(1, "Original", task.PriorityMedium),
+		task.AddTask(2, "Added by self", task.PriorityLow),
+	}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if reloadCount != 0 {
+		t.Errorf("expected no reload for self-write, got %d reload(s)", reloadCount)
+	}
+}
+
+func TestWatcher_PublishesEventsOnBus(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "tasks.txt")
+	store := NewStore(OSBackend{}, filePath)
+
+	if err := store.Save([]task.Task{}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	bus := events.NewBus()
+	if err := store.EnableWatcher(10*time.Millisecond, bus); err != nil {
+		t.Fatalf("EnableWatcher failed: %v", err)
+	}
+	ch, cancelSub := bus.Subscribe()
+	defer cancelSub()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var wg sync.WaitGroup
+	store.RunWatcher(ctx, &wg)
+
+	external := NewStore(OSBackend{}, filePath)
+	time.Sleep(10 * time.Millisecond)
+	if err := external.Save([]task.Task{task.AddTask(1, "From outside", task.PriorityHigh)}); err != nil {
+		t.Fatalf("external Save failed: %v", err)
+	}
+
+	select {
+	case e := <-ch:
+		if e.Kind != events.KindTaskAdded {
+			t.Errorf("expected KindTaskAdded, got %v", e.Kind)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for reload event on bus")
+	}
+}
@@ -0,0 +1,230 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"taskm/pkg/task"
+	"time"
+)
+
+// WritebackStore - надстройка над Store, которая держит задачи в памяти и
+// откладывает запись на диск: мутации (Add/Complete/Delete/Edit) обновляют
+// только in-memory карту и помечают измененные ID как "грязные", а полный
+// снэпшот на диск пишется либо фоновой горутиной раз в interval (см. Run),
+// либо сразу же, если interval <= 0 (синхронный режим, как вело себя старое
+// storage.SaveTasks на каждую мутацию). Чтение (List/Get) всегда
+// обслуживается из памяти.
+type WritebackStore struct {
+	store    *Store
+	interval time.Duration
+
+	mu        sync.Mutex
+	tasks     map[int]task.Task
+	dirty     map[int]struct{}
+	maxID     int
+	lastFlush time.Time
+}
+
+// NewWritebackStore загружает текущее состояние из store в память и
+// возвращает WritebackStore поверх него. interval <= 0 означает синхронный
+// режим: каждая мутация сразу же пишется на диск.
+func NewWritebackStore(store *Store, interval time.Duration) (*WritebackStore, error) {
+	loaded, maxID, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make(map[int]task.Task, len(loaded))
+	for _, t := range loaded {
+		tasks[t.ID] = t
+	}
+
+	return &WritebackStore{
+		store:    store,
+		interval: interval,
+		tasks:    tasks,
+		dirty:    make(map[int]struct{}),
+		maxID:    maxID,
+	}, nil
+}
+
+// Add добавляет (или перезаписывает) задачу t в памяти и помечает ее как
+// грязную.
+func (w *WritebackStore) Add(t task.Task) error {
+	return w.mutate(t.ID, func() {
+		w.tasks[t.ID] = t
+		if t.ID > w.maxID {
+			w.maxID = t.ID
+		}
+	})
+}
+
+// Complete отмечает задачу id завершенной (Done=true, CompletedAt=now).
+func (w *WritebackStore) Complete(id int) error {
+	return w.mutate(id, func() {
+		t, ok := w.tasks[id]
+		if !ok {
+			return
+		}
+		t.Done = true
+		t.CompletedAt = time.Now()
+		w.tasks[id] = t
+	})
+}
+
+// Delete удаляет задачу id из памяти.
+func (w *WritebackStore) Delete(id int) error {
+	return w.mutate(id, func() {
+		delete(w.tasks, id)
+	})
+}
+
+// Edit перезаписывает задачу t целиком (используется, например, редактированием
+// заголовка/приоритета/зависимостей существующей задачи).
+func (w *WritebackStore) Edit(t task.Task) error {
+	return w.mutate(t.ID, func() {
+		w.tasks[t.ID] = t
+	})
+}
+
+// mutate применяет fn под блокировкой, помечает id грязным и, в синхронном
+// режиме (interval <= 0), сразу же сбрасывает состояние на диск.
+func (w *WritebackStore) mutate(id int, fn func()) error {
+	w.mu.Lock()
+	fn()
+	w.dirty[id] = struct{}{}
+	immediate := w.interval <= 0
+	w.mu.Unlock()
+
+	if immediate {
+		return w.Sync()
+	}
+	return nil
+}
+
+// List возвращает снимок всех задач из памяти (порядок - по возрастанию ID).
+func (w *WritebackStore) List() []task.Task {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	result := make([]task.Task, 0, len(w.tasks))
+	for _, t := range w.tasks {
+		result = append(result, t)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// Get возвращает задачу id из памяти, если она существует.
+func (w *WritebackStore) Get(id int) (task.Task, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	t, ok := w.tasks[id]
+	return t, ok
+}
+
+// MaxID возвращает наибольший виденный ID (для генерации следующего).
+func (w *WritebackStore) MaxID() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.maxID
+}
+
+// DirtyCount возвращает число задач, измененных с момента последнего Sync.
+func (w *WritebackStore) DirtyCount() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.dirty)
+}
+
+// LastFlush возвращает время последнего успешного сброса на диск (нулевое
+// время, если сброса еще не было).
+func (w *WritebackStore) LastFlush() time.Time {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.lastFlush
+}
+
+// PendingBytes оценивает размер еще не сброшенных на диск изменений - сумму
+// длин строк файлового формата для всех грязных задач, которые все еще
+// присутствуют в памяти (удаленные задачи не добавляют байт).
+func (w *WritebackStore) PendingBytes() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	total := 0
+	for id := range w.dirty {
+		if t, ok := w.tasks[id]; ok {
+			total += len(formatTaskLine(t))
+		}
+	}
+	return total
+}
+
+// Sync принудительно сбрасывает текущее состояние памяти на диск, если есть
+// грязные задачи. CLI может вызывать его явно перед чтением/печатью, чтобы
+// гарантировать, что показанные данные совпадают с сохраненными.
+func (w *WritebackStore) Sync() error {
+	w.mu.Lock()
+	if len(w.dirty) == 0 {
+		w.mu.Unlock()
+		return nil
+	}
+	snapshot := make([]task.Task, 0, len(w.tasks))
+	for _, t := range w.tasks {
+		snapshot = append(snapshot, t)
+	}
+	sort.Slice(snapshot, func(i, j int) bool { return snapshot[i].ID < snapshot[j].ID })
+	w.mu.Unlock()
+
+	if err := w.store.Save(snapshot); err != nil {
+		return fmt.Errorf("writeback: не удалось сбросить %d задач(и) на диск: %w", len(snapshot), err)
+	}
+
+	w.mu.Lock()
+	w.dirty = make(map[int]struct{})
+	w.lastFlush = time.Now()
+	w.mu.Unlock()
+
+	return nil
+}
+
+// Run запускает фоновую горутину, которая сбрасывает грязные задачи на диск
+// каждые w.interval; при interval <= 0 это no-op, т.к. WritebackStore уже
+// пишет синхронно на каждую мутацию. При отмене ctx выполняется финальный
+// синхронный Sync перед завершением горутины.
+func (w *WritebackStore) Run(ctx context.Context, wg *sync.WaitGroup) {
+	if w.interval <= 0 {
+		return
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := w.Sync(); err != nil {
+					fmt.Fprintf(os.Stderr, "Writeback: ошибка периодического сброса: %v\n", err)
+				}
+			case <-ctx.Done():
+				if err := w.Sync(); err != nil {
+					fmt.Fprintf(os.Stderr, "Writeback: ошибка финального сброса при остановке: %v\n", err)
+				}
+				return
+			}
+		}
+	}()
+}
+
+// Close выполняет финальный синхронный сброс на диск - вызывается при
+// завершении работы независимо от того, использовался ли Run.
+func (w *WritebackStore) Close() error {
+	return w.Sync()
+}
@@ -0,0 +1,178 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"taskm/pkg/task"
+	"testing"
+	"time"
+)
+
+func TestWritebackStore_SyncMode_WritesImmediately(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "tasks.txt")
+	store := NewStore(OSBackend{}, filePath)
+
+	ws, err := NewWritebackStore(store, 0)
+	if err != nil {
+		t.Fatalf("NewWritebackStore failed: %v", err)
+	}
+
+	if err := ws.Add(task.AddTask(1, "Synchronous", task.PriorityHigh)); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	if ws.DirtyCount() != 0 {
+		t.Errorf("expected DirtyCount 0 right after synchronous write, got %d", ws.DirtyCount())
+	}
+
+	onDisk, _, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(onDisk) != 1 || onDisk[0].ID != 1 {
+		t.Errorf("expected task written to disk immediately, got %+v", onDisk)
+	}
+}
+
+func TestWritebackStore_AsyncMode_DefersWrite(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "tasks.txt")
+	store := NewStore(OSBackend{}, filePath)
+
+	ws, err := NewWritebackStore(store, time.Hour)
+	if err != nil {
+		t.Fatalf("NewWritebackStore failed: %v", err)
+	}
+
+	if err := ws.Add(task.AddTask(1, "Deferred", task.PriorityLow)); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	if ws.DirtyCount() != 1 {
+		t.Errorf("expected DirtyCount 1 before flush, got %d", ws.DirtyCount())
+	}
+
+	onDisk, _, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(onDisk) != 0 {
+		t.Errorf("expected nothing written to disk before flush, got %+v", onDisk)
+	}
+
+	if got := ws.List(); len(got) != 1 || got[0].Title != "Deferred" {
+		t.Errorf("expected List to serve from memory, got %+v", got)
+	}
+
+	if err := ws.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if ws.DirtyCount() != 0 {
+		t.Errorf("expected DirtyCount 0 after Sync, got %d", ws.DirtyCount())
+	}
+	if ws.LastFlush().IsZero() {
+		t.Error("expected LastFlush to be set after Sync")
+	}
+
+	onDisk, _, err = store.Load()
+	if err != nil {
+		t.Fatalf("Load after Sync failed: %v", err)
+	}
+	if len(onDisk) != 1 {
+		t.Errorf("expected task on disk after Sync, got %+v", onDisk)
+	}
+}
+
+func TestWritebackStore_DeleteRemovesFromMemoryAndDisk(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "tasks.txt")
+	store := NewStore(OSBackend{}, filePath)
+
+	ws, err := NewWritebackStore(store, 0)
+	if err != nil {
+		t.Fatalf("NewWritebackStore failed: %v", err)
+	}
+
+	if err := ws.Add(task.AddTask(1, "To delete", task.PriorityMedium)); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := ws.Delete(1); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	if _, ok := ws.Get(1); ok {
+		t.Error("expected task 1 to be gone from memory after Delete")
+	}
+
+	onDisk, _, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(onDisk) != 0 {
+		t.Errorf("expected task removed from disk, got %+v", onDisk)
+	}
+}
+
+func TestWritebackStore_PendingBytes(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "tasks.txt")
+	store := NewStore(OSBackend{}, filePath)
+
+	ws, err := NewWritebackStore(store, time.Hour)
+	if err != nil {
+		t.Fatalf("NewWritebackStore failed: %v", err)
+	}
+
+	if ws.PendingBytes() != 0 {
+		t.Errorf("expected 0 pending bytes initially, got %d", ws.PendingBytes())
+	}
+
+	if err := ws.Add(task.AddTask(1, "Some task", task.PriorityHigh)); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if ws.PendingBytes() <= 0 {
+		t.Error("expected positive PendingBytes after a dirty write")
+	}
+}
+
+func TestWritebackStore_RunFlushesOnContextCancel(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "tasks.txt")
+	store := NewStore(OSBackend{}, filePath)
+
+	ws, err := NewWritebackStore(store, time.Hour)
+	if err != nil {
+		t.Fatalf("NewWritebackStore failed: %v", err)
+	}
+	if err := ws.Add(task.AddTask(1, "Flush on shutdown", task.PriorityLow)); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	ws.Run(ctx, &wg)
+
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for writeback goroutine to finish after context cancel")
+	}
+
+	onDisk, _, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(onDisk) != 1 {
+		t.Errorf("expected final flush to write the dirty task, got %+v", onDisk)
+	}
+}
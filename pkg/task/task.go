@@ -2,6 +2,7 @@ package task
 
 import (
 	//"flag"
+	"taskm/pkg/result"
 	"time"
 )
 
@@ -12,11 +13,22 @@ const(
 )
 
 type Task struct {
-	CreatedAt time.Time
-	Title     string
-	Priority  string
-	ID        int
-	Done      bool
+	CreatedAt   time.Time
+	CompletedAt time.Time     // время, когда Done выставили в true; нулевое, пока задача не завершена
+	Title       string
+	Priority    string
+	ID          int
+	Done        bool
+	Retention   time.Duration // сколько хранить задачу в архиве после завершения (0 = бессрочно)
+	DependsOn   []int         // ID задач, которые должны быть завершены раньше этой
+	HasNotes    bool          // есть ли у задачи прикрепленный результат/заметка (см. ResultWriter)
+}
+
+// ResultWriter возвращает io.Writer для дозаписи результата/заметки этой
+// задачи в sidecar-файл реестра reg (storage/results/<id>.log и т.п.).
+func (t *Task) ResultWriter(reg *result.Registry) *result.Writer {
+	t.HasNotes = true
+	return reg.Writer(t.ID)
 }
 
 func (t *Task) AddTask(title string, priority string) {
@@ -27,6 +39,18 @@ func (t *Task) AddTask(title string, priority string) {
 }
 
 func AddTask(id int, title string, priority string) Task {
+	return AddTaskWithOptions(id, title, priority, 0, nil)
+}
+
+// AddTaskWithRetention - то же самое, что и AddTask, но дополнительно задает
+// TTL хранения задачи в архиве после ее завершения.
+func AddTaskWithRetention(id int, title string, priority string, retention time.Duration) Task {
+	return AddTaskWithOptions(id, title, priority, retention, nil)
+}
+
+// AddTaskWithOptions - то же самое, что и AddTask, но дополнительно задает TTL
+// хранения в архиве и список ID задач, от которых зависит эта задача.
+func AddTaskWithOptions(id int, title string, priority string, retention time.Duration, dependsOn []int) Task {
 	if priority != PriorityHigh && priority != PriorityMedium && priority != PriorityLow {
         priority = PriorityMedium
     }
@@ -36,6 +60,8 @@ func AddTask(id int, title string, priority string) Task {
 		Done:    false,
 		CreatedAt: time.Now(),
 		Priority: priority,
+		Retention: retention,
+		DependsOn: dependsOn,
 	}
 }
 
@@ -1,6 +1,7 @@
 package task
 
 import (
+	"taskm/pkg/result"
 	"testing"
 	"time"
 )
@@ -95,4 +96,30 @@ func TestPriorityValue(t *testing.T) {
 			t.Errorf("For priority '%s', expected value %d, got %d", tc.priority, tc.expected, value)
 		}
 	}
+}
+
+func TestResultWriter_SetsHasNotesAndWrites(t *testing.T) {
+	reg := result.NewRegistry(t.TempDir())
+	task := Task{ID: 1}
+
+	if task.HasNotes {
+		t.Fatal("expected HasNotes to be false before writing a result")
+	}
+
+	w := task.ResultWriter(reg)
+	if _, err := w.Write([]byte("done")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	if !task.HasNotes {
+		t.Error("expected HasNotes to be true after ResultWriter is used")
+	}
+
+	data, err := reg.Read(task.ID)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if string(data) != "done" {
+		t.Errorf("expected sidecar content %q, got %q", "done", string(data))
+	}
 }
\ No newline at end of file